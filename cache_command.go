@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubewarden/k8s-objects-generator/input"
+)
+
+const cacheCommandUsage = `Usage: k8s-objects-generator cache <fetch|list> [flags] [versions...]
+
+Manage the local cache of the swagger files of Kubernetes.
+
+Commands:
+  fetch   download the swagger files of the given Kubernetes versions into the cache
+  list    list the Kubernetes versions that are cached
+
+Flags:
+`
+
+// runCacheCommand implements the `cache` subcommand.
+func runCacheCommand(args []string) error {
+	flags := flag.NewFlagSet("cache", flag.ExitOnError)
+	var download downloadFlags
+	var writeLockFile string
+	download.register(flags)
+	flags.StringVar(&writeLockFile, "write-lock", "", "Record the SHA-256 digests of the fetched swagger files inside of this lock file")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), cacheCommandUsage)
+		flags.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		flags.Usage()
+		return errors.New("a cache command must be specified")
+	}
+	command := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	downloader, err := download.newDownloader()
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "fetch":
+		if flags.NArg() == 0 {
+			return errors.New("at least one Kubernetes version must be specified")
+		}
+		return fetchSwaggers(downloader, flags.Args(), writeLockFile)
+	case "list":
+		return printCachedSwaggers(os.Stdout, downloader.Cache)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown cache command '%s'", command)
+	}
+}
+
+// fetchSwaggers adds the swagger files of the given Kubernetes versions to
+// the cache. Their digests are recorded inside of the lock file, when one is
+// specified.
+func fetchSwaggers(downloader *input.SwaggerDownloader, kubeVersions []string, writeLockFile string) error {
+	var lock *input.SwaggerLock
+	if writeLockFile != "" {
+		var err error
+		if lock, err = input.LoadSwaggerLock(writeLockFile); err != nil {
+			return err
+		}
+	}
+
+	for _, kubeVersion := range kubeVersions {
+		swaggerData, err := downloader.Download(kubeVersion)
+		if err != nil {
+			return err
+		}
+		if lock != nil {
+			lock.Set(swaggerData.KubernetesVersion, input.Digest(swaggerData.Data))
+		}
+	}
+
+	if lock != nil {
+		return lock.Save()
+	}
+	return nil
+}
+
+func printCachedSwaggers(out io.Writer, cache *input.SwaggerCache) error {
+	entries, err := cache.List()
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // padding of the table
+	fmt.Fprintln(writer, "VERSION\tSHA256\tSIZE")
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s\t%s\t%d\n", entry.KubernetesVersion, entry.Digest, entry.Size)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+// driftCheck generates the files inside of a temporary directory, and
+// compares them with the existing output directory, which is never changed.
+type driftCheck struct {
+	// existingDir is the output directory being checked
+	existingDir string
+	tempDir     string
+}
+
+// newDriftCheck redirects the output of the project to a temporary
+// directory. When no input is given, the Kubernetes version recorded inside of
+// the existing output directory is generated.
+func newDriftCheck(project *split.Project, inputs *inputFlags) (*driftCheck, error) {
+	tempDir, err := os.MkdirTemp("", "k8s-objects-generator-check-")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create the temporary directory of the check")
+	}
+
+	check := driftCheck{
+		existingDir: project.Target,
+		tempDir:     tempDir,
+	}
+	if project.Layout == split.LayoutGOPATH && !project.ExistingModule {
+		project.OutputDir = tempDir
+	}
+	project.Target = filepath.Join(tempDir, filepath.Base(check.existingDir))
+	project.Root = project.Target
+
+	if !inputs.specified() {
+		kubernetesVersion, err := recordedKubernetesVersion(check.existingDir)
+		if err != nil {
+			check.cleanup()
+			return nil, err
+		}
+		slog.Info("Checking the recorded Kubernetes version", "version", kubernetesVersion)
+		inputs.kubeVersion = kubernetesVersion
+	}
+
+	return &check, nil
+}
+
+// recordedKubernetesVersion returns the Kubernetes version the files of the
+// output directory have been generated from. The files generated from
+// several inputs, like the ones of multiple Kubernetes versions or of CRDs,
+// cannot be regenerated from the version only.
+func recordedKubernetesVersion(outputDir string) (string, error) {
+	kubernetesVersionFile := filepath.Join(outputDir, "KUBERNETES_VERSION")
+	data, err := os.ReadFile(kubernetesVersionFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read the Kubernetes version recorded inside of %s, the inputs must be specified", outputDir)
+	}
+
+	kubernetesVersion := strings.TrimSpace(string(data))
+	if kubernetesVersion == "" || kubernetesVersion == unknownKubernetesVersion {
+		return "", fmt.Errorf("the files of %s have not been generated from a known Kubernetes version, the inputs must be specified", outputDir)
+	}
+	if strings.Contains(kubernetesVersion, ",") {
+		return "", fmt.Errorf("the files of %s have been generated from several Kubernetes versions, %s, the inputs must be specified", outputDir, kubernetesVersion)
+	}
+
+	// the metadata of the older generators doesn't record the inputs
+	metadata, err := split.ReadMetadata(afero.NewOsFs(), outputDir)
+	if err == nil && metadata.Provenance != nil && len(metadata.Inputs) > 1 {
+		return "", fmt.Errorf("the files of %s have been generated from %d inputs, the inputs must be specified", outputDir, len(metadata.Inputs))
+	}
+	return kubernetesVersion, nil
+}
+
+// compare prints the unified diff of each file that differs, and exits with
+// status 1 when there's at least one.
+func (c *driftCheck) compare(project *split.Project) {
+	diffs, err := split.DiffTrees(afero.NewOsFs(), c.existingDir, project.Root)
+	c.cleanup()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, diff := range diffs {
+		fmt.Print(diff.Diff)
+	}
+	if len(diffs) > 0 {
+		log.Fatalf("%d files of %s differ from the generated ones", len(diffs), c.existingDir)
+	}
+	slog.Info("The output directory is up to date", "path", c.existingDir)
+}
+
+func (c *driftCheck) cleanup() {
+	if err := os.RemoveAll(c.tempDir); err != nil {
+		slog.Warn("Cannot remove the temporary directory of the check", "path", c.tempDir, "error", err)
+	}
+}
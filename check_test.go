@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+// writeOutput writes the files recording the provenance of an output
+// directory.
+func writeOutput(t *testing.T, kubernetesVersion, metadata string) string {
+	t.Helper()
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "KUBERNETES_VERSION"), []byte(kubernetesVersion), 0o600))
+	if metadata != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(outputDir, split.MetadataFileName), []byte(metadata), 0o600))
+	}
+	return outputDir
+}
+
+func TestRecordedKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		kubernetesVersion string
+		metadata          string
+		expected          string
+		expectedError     string
+	}{
+		{
+			name:              "version",
+			kubernetesVersion: "1.33\n",
+			expected:          "1.33",
+		},
+		{
+			name:              "version with its input",
+			kubernetesVersion: "1.33.0",
+			metadata:          `{"kubernetesVersion":"1.33.0","inputs":[{"source":"1.33.0","sha256":"00"}]}`,
+			expected:          "1.33.0",
+		},
+		{
+			name:              "unknown version",
+			kubernetesVersion: "unknown",
+			expectedError:     "have not been generated from a known Kubernetes version, the inputs must be specified",
+		},
+		{
+			name:          "empty file",
+			expectedError: "have not been generated from a known Kubernetes version, the inputs must be specified",
+		},
+		{
+			name:              "several versions",
+			kubernetesVersion: "1.30,1.31",
+			expectedError:     "have been generated from several Kubernetes versions, 1.30,1.31, the inputs must be specified",
+		},
+		{
+			name:              "several inputs",
+			kubernetesVersion: "1.33",
+			metadata:          `{"kubernetesVersion":"1.33","inputs":[{"source":"1.33","sha256":"00"},{"source":"crds","sha256":"01"}]}`,
+			expectedError:     "have been generated from 2 inputs, the inputs must be specified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := writeOutput(t, test.kubernetesVersion, test.metadata)
+			kubernetesVersion, err := recordedKubernetesVersion(outputDir)
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, kubernetesVersion)
+		})
+	}
+
+	_, err := recordedKubernetesVersion(t.TempDir())
+	require.ErrorContains(t, err, "cannot read the Kubernetes version recorded inside of")
+}
+
+func TestNewDriftCheck(t *testing.T) {
+	outputDir := writeOutput(t, "1.33", "")
+	project, err := split.NewProject(outputDir, "example.com/objects", split.LayoutModule)
+	require.NoError(t, err)
+
+	// the recorded version is generated when no input is given
+	inputs := inputFlags{}
+	check, err := newDriftCheck(&project, &inputs)
+	require.NoError(t, err)
+	assert.Equal(t, "1.33", inputs.kubeVersion)
+	assert.Equal(t, outputDir, check.existingDir)
+	assert.Equal(t, filepath.Join(check.tempDir, filepath.Base(outputDir)), project.Target)
+	assert.Equal(t, project.Target, project.Root)
+	check.cleanup()
+	assert.NoDirExists(t, check.tempDir)
+
+	// the given inputs are used
+	project, err = split.NewProject(outputDir, "example.com/objects", split.LayoutModule)
+	require.NoError(t, err)
+	inputs = inputFlags{swaggerFiles: stringSliceFlag{"swagger.json"}}
+	check, err = newDriftCheck(&project, &inputs)
+	require.NoError(t, err)
+	defer check.cleanup()
+	assert.Empty(t, inputs.kubeVersion)
+}
+
+func TestNewDriftCheckGOPATHLayout(t *testing.T) {
+	outputDir := t.TempDir()
+	project, err := split.NewProject(outputDir, "example.com/objects", split.LayoutGOPATH)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(project.Target, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(project.Target, "KUBERNETES_VERSION"), []byte("1.30,1.31"), 0o600))
+
+	_, err = newDriftCheck(&project, &inputFlags{})
+	require.ErrorContains(t, err, "have been generated from several Kubernetes versions")
+
+	project, err = split.NewProject(outputDir, "example.com/objects", split.LayoutGOPATH)
+	require.NoError(t, err)
+	existingDir := project.Target
+	check, err := newDriftCheck(&project, &inputFlags{kubeVersion: "1.33"})
+	require.NoError(t, err)
+	defer check.cleanup()
+	// the GOPATH of the go commands is the temporary directory
+	assert.Equal(t, existingDir, check.existingDir)
+	assert.Equal(t, check.tempDir, project.OutputDir)
+}
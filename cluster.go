@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubewarden/k8s-objects-generator/input"
+)
+
+// clusterFlags holds the flags that control how the OpenAPI document is
+// fetched from a live cluster.
+type clusterFlags struct {
+	enabled    bool
+	kubeconfig string
+	context    string
+	openAPIv3  bool
+}
+
+func (c *clusterFlags) register(flags *flag.FlagSet) {
+	flags.BoolVar(&c.enabled, "from-cluster", false, "Fetch the OpenAPI document served by the API server of the cluster defined inside of the kubeconfig")
+	flags.StringVar(&c.kubeconfig, "kubeconfig", "", "The kubeconfig file to use with `-from-cluster`. Defaults to $KUBECONFIG, or ~/.kube/config")
+	flags.StringVar(&c.context, "kube-context", "", "The kubeconfig context to use with `-from-cluster`. Defaults to the current context")
+	flags.BoolVar(&c.openAPIv3, "cluster-openapi-v3", false, "Query the /openapi/v3 endpoint of the cluster instead of the /openapi/v2 one")
+}
+
+// newSource returns the cluster source configured by the flags.
+func (c *clusterFlags) newSource() *input.ClusterSource {
+	source := input.NewClusterSource()
+	source.Context = c.context
+	source.OpenAPIv3 = c.openAPIv3
+	if c.kubeconfig != "" {
+		source.KubeconfigFiles = filepath.SplitList(c.kubeconfig)
+	}
+	return source
+}
+
+// source describes the cluster, to report where the definitions come from.
+func (c *clusterFlags) source() string {
+	if c.context == "" {
+		return "cluster (current context)"
+	}
+	return fmt.Sprintf("cluster (context %s)", c.context)
+}
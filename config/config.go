@@ -0,0 +1,493 @@
+// Package config reads the configuration file of the generator.
+//
+// The file gathers the options that can be set via the command line flags,
+// plus the ones that are too structured for a flag: the initialisms, the
+// patches of the definitions and the Go types of the swagger formats. The
+// command line flags override the values of the file.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/mod/module"
+
+	"github.com/kubewarden/k8s-objects-generator/emitter"
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+// FileName is the name of the configuration file looked up inside of the
+// working directory.
+const FileName = "k8s-objects-generator.yaml"
+
+// CurrentVersion is the version of the format of the configuration file.
+const CurrentVersion = 1
+
+// goModCheckPath is the module path of the go.mod files used to validate
+// the go.mod settings.
+const goModCheckPath = "example.com/module"
+
+// Config is the content of the configuration file.
+type Config struct {
+	// Version of the format of the file, must be CurrentVersion
+	Version   int       `yaml:"version"`
+	Inputs    Inputs    `yaml:"inputs"`
+	Output    Output    `yaml:"output"`
+	Module    Module    `yaml:"module"`
+	Selection Selection `yaml:"selection"`
+	// PackageMapping rules are evaluated before the default ones
+	PackageMapping []swaggerhelpers.PackageMappingRule `yaml:"packageMapping"`
+	// Initialisms are added to the built-in ones, e.g. `HPA`
+	Initialisms []string `yaml:"initialisms"`
+	// Patches are applied to the definitions after the default ones
+	Patches       []swaggerhelpers.DefinitionPatch `yaml:"patches"`
+	TypeOverrides []TypeOverride                   `yaml:"typeOverrides"`
+}
+
+// Inputs are the OpenAPI documents to process, see the `-f`, `-openapi-v3`,
+// `-kube-version` and `-crd` flags.
+type Inputs struct {
+	SwaggerFiles      []string  `yaml:"swaggerFiles"`
+	OpenAPIv3         string    `yaml:"openAPIv3"`
+	KubernetesVersion string    `yaml:"kubernetesVersion"`
+	CRDs              []string  `yaml:"crds"`
+	Cluster           *Cluster  `yaml:"cluster"`
+	Download          *Download `yaml:"download"`
+}
+
+// Cluster enables the fetching of the OpenAPI document of a cluster, see the
+// `-from-cluster` flag.
+type Cluster struct {
+	Kubeconfig string `yaml:"kubeconfig"`
+	Context    string `yaml:"context"`
+	OpenAPIv3  bool   `yaml:"openAPIv3"`
+}
+
+// Download configures how the swagger files of Kubernetes are downloaded.
+type Download struct {
+	CacheDir    string   `yaml:"cacheDir"`
+	Offline     bool     `yaml:"offline"`
+	SwaggerURLs []string `yaml:"swaggerURLs"`
+	Retries     *int     `yaml:"retries"`
+	// Timeout of each attempt, like `30s`
+	Timeout  string `yaml:"timeout"`
+	LockFile string `yaml:"lockFile"`
+}
+
+// Output configures where the packages are generated.
+type Output struct {
+	// Dir is the root directory of the generated files, see the `-o` flag
+	Dir string `yaml:"dir"`
+	// Layout of the module inside of Dir, `gopath` or `module`, see the
+	// `-layout` flag
+	Layout string `yaml:"layout"`
+	// ModuleDir is a directory of an existing module, see the `-module-dir`
+	// flag
+	ModuleDir string `yaml:"moduleDir"`
+	// Jobs is the number of packages generated concurrently
+	Jobs int `yaml:"jobs"`
+	// ModelCache can be set to false to disable the cache of the models
+	ModelCache *bool `yaml:"modelCache"`
+	// Verify can be set to false to skip the build and the vet of the
+	// generated packages
+	Verify *bool `yaml:"verify"`
+	// Overlays are merged into the output directory after the generation, in
+	// order, see the `-overlay` flag
+	Overlays []Overlay `yaml:"overlays"`
+}
+
+// Overlay is a directory of hand-written files merged into the output
+// directory.
+type Overlay struct {
+	Dir string `yaml:"dir" json:"dir,omitempty"`
+	// Policy applied when a file already exists: `replace`, `add` or `fail`,
+	// the default
+	Policy string `yaml:"policy" json:"policy,omitempty"`
+}
+
+// Module configures the generated Go module.
+type Module struct {
+	// Path of the module, see the `-repo` flag
+	Path string `yaml:"path"`
+	// GoModule holds the settings of the go.mod file. The `go` directive
+	// defaults to split.DefaultGoVersion, the replace directives replace the
+	// default ones when set, an empty list removes them
+	split.GoModule `yaml:",inline"`
+}
+
+// Selection restricts the generated definitions, see the `-include` and
+// `-exclude` flags.
+type Selection struct {
+	Include []string `yaml:"include" json:"include,omitempty"`
+	Exclude []string `yaml:"exclude" json:"exclude,omitempty"`
+}
+
+// TypeOverride replaces a generated Go type with an existing one. It either
+// maps a swagger format to a Go type, replacing the built-in mapping, or
+// replaces the type of a definition, which is then not generated.
+type TypeOverride struct {
+	// Format of the schemas, like `date-time`
+	Format string `yaml:"format" json:"format,omitempty"`
+	// Definition is the id of the replaced definition, like
+	// `io.k8s.apimachinery.pkg.api.resource.Quantity`
+	Definition string `yaml:"definition" json:"definition,omitempty"`
+	// Type is the name of the Go type
+	Type   string     `yaml:"type" json:"type,omitempty"`
+	Import TypeImport `yaml:"import" json:"import"`
+	// MarshalJSON must be true when the type implements json.Marshaler and
+	// json.Unmarshaler, and isn't a string or a number. Only used together
+	// with Format
+	MarshalJSON bool `yaml:"marshalJSON" json:"marshalJSON,omitempty"`
+}
+
+// TypeImport is the package providing the type of an override, it's empty
+// for the predeclared types.
+type TypeImport struct {
+	Package string `yaml:"package" json:"package,omitempty"`
+	Alias   string `yaml:"alias" json:"alias,omitempty"`
+}
+
+// ValidationError lists all the problems found inside of a configuration
+// file.
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration file %s:\n  - %s", e.Path, strings.Join(e.Problems, "\n  - "))
+}
+
+// Load reads and validates the configuration file. The relative paths of the
+// file are resolved against its directory.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read configuration file %s", path)
+	}
+
+	config := Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrapf(err, "cannot decode configuration file %s", path)
+	}
+
+	if problems := config.validate(); len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot calculate absolute path of %s", path)
+	}
+	config.resolvePaths(filepath.Dir(absPath))
+	config.setDefaults()
+
+	return &config, nil
+}
+
+// validate returns the problems of the configuration, prefixed by the field
+// they are about.
+func (c *Config) validate() []string {
+	problems := []string{}
+	addProblem := func(field, format string, args ...interface{}) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.Version != CurrentVersion {
+		addProblem("version", "unsupported version %d, it must be %d", c.Version, CurrentVersion)
+	}
+
+	if download := c.Inputs.Download; download != nil {
+		if download.Retries != nil && *download.Retries < 0 {
+			addProblem("inputs.download.retries", "must not be negative")
+		}
+		if download.Timeout != "" {
+			if timeout, err := time.ParseDuration(download.Timeout); err != nil || timeout <= 0 {
+				addProblem("inputs.download.timeout", "'%s' is not a positive duration, like `30s`", download.Timeout)
+			}
+		}
+	}
+
+	if c.Output.Jobs < 0 {
+		addProblem("output.jobs", "must be at least 1")
+	}
+	if c.Output.ModuleDir != "" {
+		if c.Output.Dir != "" {
+			addProblem("output.moduleDir", "cannot be used together with output.dir")
+		}
+		if c.Output.Layout != "" {
+			addProblem("output.moduleDir", "cannot be used together with output.layout")
+		}
+		if c.Module.Path != "" {
+			addProblem("output.moduleDir", "cannot be used together with module.path")
+		}
+		if c.Module.hasGoModSettings() {
+			addProblem("output.moduleDir", "cannot be used together with the go.mod settings of module")
+		}
+	}
+	if c.Output.Layout != "" {
+		if _, err := split.ParseLayout(c.Output.Layout); err != nil {
+			addProblem("output.layout", "%s", err)
+		}
+	}
+	for i, overlay := range c.Output.Overlays {
+		field := fmt.Sprintf("output.overlays[%d]", i)
+		if overlay.Dir == "" {
+			addProblem(field, "dir must be set")
+		}
+		if _, err := split.ParseOverlayPolicy(overlay.Policy); overlay.Policy != "" && err != nil {
+			addProblem(field, "%s", err)
+		}
+	}
+	if c.Module.Path != "" {
+		if err := module.CheckImportPath(c.Module.Path); err != nil {
+			addProblem("module.path", "%s", err)
+		}
+	}
+	c.Module.validateGoMod(addProblem)
+
+	for i, value := range c.Selection.Include {
+		if _, err := split.ParseSelector(value); err != nil {
+			addProblem(fmt.Sprintf("selection.include[%d]", i), "%s", err)
+		}
+	}
+	for i, value := range c.Selection.Exclude {
+		if _, err := split.ParseSelector(value); err != nil {
+			addProblem(fmt.Sprintf("selection.exclude[%d]", i), "%s", err)
+		}
+	}
+
+	if _, err := swaggerhelpers.NewPackageMapping(c.PackageMapping); err != nil {
+		addProblem("packageMapping", "%s", err)
+	}
+
+	for i, initialism := range c.Initialisms {
+		if !isInitialism(initialism) {
+			addProblem(fmt.Sprintf("initialisms[%d]", i), "'%s' must be made of letters and digits", initialism)
+		}
+	}
+
+	patchNames := map[string]bool{}
+	for i, patch := range c.Patches {
+		field := fmt.Sprintf("patches[%d]", i)
+		if err := patch.Validate(); err != nil {
+			addProblem(field, "%s", err)
+		}
+		if patch.Name != "" {
+			if patchNames[patch.Name] {
+				addProblem(field, "name '%s' is already used", patch.Name)
+			}
+			patchNames[patch.Name] = true
+		}
+	}
+
+	formats, definitions := map[string]bool{}, map[string]bool{}
+	for i, override := range c.TypeOverrides {
+		field := fmt.Sprintf("typeOverrides[%d]", i)
+		switch {
+		case override.Format == "" && override.Definition == "":
+			addProblem(field, "either format or definition must be set")
+		case override.Format != "" && override.Definition != "":
+			addProblem(field, "format and definition cannot be used at the same time")
+		case override.Format != "":
+			if formats[override.Format] {
+				addProblem(field, "format '%s' is already overridden", override.Format)
+			}
+			formats[override.Format] = true
+		default:
+			if definitions[override.Definition] {
+				addProblem(field, "definition '%s' is already overridden", override.Definition)
+			}
+			definitions[override.Definition] = true
+			if override.Import.Package == "" {
+				addProblem(field, "import.package must be set when overriding a definition")
+			}
+			if override.MarshalJSON {
+				addProblem(field, "marshalJSON can be used only together with format")
+			}
+		}
+
+		if !token.IsIdentifier(override.Type) {
+			addProblem(field, "type '%s' is not a valid Go identifier", override.Type)
+		}
+		if override.Import.Package != "" {
+			if err := module.CheckImportPath(override.Import.Package); err != nil {
+				addProblem(field, "%s", err)
+			}
+		} else if override.Import.Alias != "" {
+			addProblem(field, "import.alias cannot be set without import.package")
+		}
+		if override.Import.Alias != "" && !token.IsIdentifier(override.Import.Alias) {
+			addProblem(field, "alias '%s' is not a valid Go identifier", override.Import.Alias)
+		}
+	}
+
+	return problems
+}
+
+func (m *Module) hasGoModSettings() bool {
+	return m.Go != "" || m.Toolchain != "" || len(m.Require) > 0 || m.Replace != nil
+}
+
+// validateGoMod checks the go.mod settings one by one, by parsing a go.mod
+// file holding only the checked setting.
+func (m *Module) validateGoMod(addProblem func(field, format string, args ...interface{})) {
+	check := func(field string, goModule split.GoModule) {
+		if goModule.Go == "" {
+			goModule.Go = split.DefaultGoVersion
+		}
+		if _, err := goModule.GoModFile(goModCheckPath); err != nil {
+			addProblem(field, "%s", err)
+		}
+	}
+
+	if m.Go != "" {
+		check("module.go", split.GoModule{Go: m.Go})
+	}
+	if m.Toolchain != "" {
+		check("module.toolchain", split.GoModule{Toolchain: m.Toolchain})
+	}
+	for i, require := range m.Require {
+		check(fmt.Sprintf("module.require[%d]", i), split.GoModule{Require: []split.ModuleVersion{require}})
+	}
+	for i, replace := range m.Replace {
+		check(fmt.Sprintf("module.replace[%d]", i), split.GoModule{Replace: []split.ModuleReplace{replace}})
+	}
+}
+
+func isInitialism(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePaths makes the relative paths of the configuration relative to
+// `dir`.
+func (c *Config) resolvePaths(dir string) {
+	resolve := func(path *string) {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+
+	for i := range c.Inputs.SwaggerFiles {
+		resolve(&c.Inputs.SwaggerFiles[i])
+	}
+	resolve(&c.Inputs.OpenAPIv3)
+	for i := range c.Inputs.CRDs {
+		resolve(&c.Inputs.CRDs[i])
+	}
+	if c.Inputs.Cluster != nil {
+		resolve(&c.Inputs.Cluster.Kubeconfig)
+	}
+	if c.Inputs.Download != nil {
+		resolve(&c.Inputs.Download.CacheDir)
+		resolve(&c.Inputs.Download.LockFile)
+	}
+	resolve(&c.Output.Dir)
+	resolve(&c.Output.ModuleDir)
+	for i := range c.Output.Overlays {
+		resolve(&c.Output.Overlays[i].Dir)
+	}
+}
+
+// setDefaults sets the values of the optional fields. The unnamed patches
+// are named after their field, like `patches[0]`, for the report of the
+// applied patches.
+func (c *Config) setDefaults() {
+	for i := range c.Patches {
+		if c.Patches[i].Name == "" {
+			c.Patches[i].Name = fmt.Sprintf("patches[%d]", i)
+		}
+	}
+	for i := range c.Output.Overlays {
+		if c.Output.Overlays[i].Policy == "" {
+			c.Output.Overlays[i].Policy = string(split.OverlayFail)
+		}
+	}
+}
+
+// GoModule returns the settings of the go.mod file of the generated module,
+// the unset ones have their default value.
+func (c *Config) GoModule() split.GoModule {
+	goModule := split.DefaultGoModule()
+	if c.Module.Go != "" {
+		goModule.Go = c.Module.Go
+	}
+	goModule.Toolchain = c.Module.Toolchain
+	goModule.Require = c.Module.Require
+	if c.Module.Replace != nil {
+		goModule.Replace = c.Module.Replace
+	}
+	return goModule
+}
+
+// EmitterOptions returns the options of the emitter defined by the
+// configuration.
+func (c *Config) EmitterOptions() emitter.Options {
+	options := emitter.Options{
+		Initialisms: c.Initialisms,
+	}
+	for _, override := range c.TypeOverrides {
+		if override.Format == "" {
+			continue
+		}
+		options.FormatTypes = append(options.FormatTypes, emitter.FormatType{
+			Format:        override.Format,
+			Package:       override.Import.Package,
+			Alias:         override.Import.Alias,
+			Type:          override.Type,
+			JSONMarshaler: override.MarshalJSON,
+		})
+	}
+	return options
+}
+
+// Dependencies returns the packages the generated files can import, besides
+// the standard library and the generated packages: the ones used by the
+// emitter and the ones of the type overrides.
+func (c *Config) Dependencies() []string {
+	dependencies := emitter.Dependencies()
+	for _, override := range c.TypeOverrides {
+		if override.Import.Package != "" {
+			dependencies = append(dependencies, override.Import.Package)
+		}
+	}
+	return dependencies
+}
+
+// DefinitionTypeOverrides returns the definitions replaced by existing Go
+// types.
+func (c *Config) DefinitionTypeOverrides() []swaggerhelpers.TypeOverride {
+	overrides := []swaggerhelpers.TypeOverride{}
+	for _, override := range c.TypeOverrides {
+		if override.Definition == "" {
+			continue
+		}
+		overrides = append(overrides, swaggerhelpers.TypeOverride{
+			Definition: override.Definition,
+			Package:    override.Import.Package,
+			Alias:      override.Import.Alias,
+			Type:       override.Type,
+		})
+	}
+	return overrides
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/emitter"
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+func TestLoad(t *testing.T) {
+	optional := false
+	config, err := Load(filepath.Join("testdata", "valid.yaml"))
+	require.NoError(t, err)
+
+	testdata, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.33", config.Inputs.KubernetesVersion)
+	// the paths are relative to the directory of the file
+	assert.Equal(t, []string{filepath.Join(testdata, "crds")}, config.Inputs.CRDs)
+	assert.Equal(t, filepath.Join(testdata, "out"), config.Output.Dir)
+	assert.Equal(t, "module", config.Output.Layout)
+	assert.Equal(t, []Overlay{
+		{Dir: filepath.Join(testdata, "helpers"), Policy: "fail"},
+		{Dir: "/srv/overrides", Policy: "replace"},
+	}, config.Output.Overlays)
+	require.NotNil(t, config.Inputs.Download)
+	require.NotNil(t, config.Inputs.Download.Retries)
+	assert.Equal(t, 0, *config.Inputs.Download.Retries)
+	assert.Equal(t, "1m", config.Inputs.Download.Timeout)
+	assert.Nil(t, config.Inputs.Cluster)
+	assert.Equal(t, 2, config.Output.Jobs)
+	require.NotNil(t, config.Output.ModelCache)
+	assert.False(t, *config.Output.ModelCache)
+	require.NotNil(t, config.Output.Verify)
+	assert.False(t, *config.Output.Verify)
+	assert.Equal(t, "example.com/objects", config.Module.Path)
+	// the empty list of replace directives removes the default ones
+	assert.Equal(t, split.GoModule{
+		Go:        "1.24",
+		Toolchain: "go1.24.2",
+		Require:   []split.ModuleVersion{{Path: "github.com/kubewarden/k8s-objects", Version: "v1.33.0"}},
+		Replace:   []split.ModuleReplace{},
+	}, config.GoModule())
+	assert.Equal(t, []string{"gv:apps/v1"}, config.Selection.Include)
+	assert.Equal(t, []swaggerhelpers.PackageMappingRule{{Prefix: "com.example.", Package: "example"}}, config.PackageMapping)
+	assert.Equal(t, []swaggerhelpers.DefinitionPatch{
+		{
+			// the unnamed patches are named after their field
+			Name:       "patches[0]",
+			Definition: "io.k8s.api.core.v1.PodSpec",
+			Extensions: map[string]interface{}{"x-omitempty": true},
+		},
+		{
+			Name:       "optional-status",
+			Definition: "io.k8s.api.*",
+			Path:       "$.properties.status",
+			Required:   &optional,
+		},
+	}, config.Patches)
+
+	assert.Equal(t, emitter.Options{
+		Initialisms: []string{"HPA"},
+		FormatTypes: []emitter.FormatType{
+			{Format: "date-time", Package: "time", Type: "Time", JSONMarshaler: true},
+		},
+	}, config.EmitterOptions())
+	assert.Equal(t, []swaggerhelpers.TypeOverride{
+		{
+			Definition: "io.k8s.apimachinery.pkg.api.resource.Quantity",
+			Package:    "example.com/quantity",
+			Alias:      "qty",
+			Type:       "Quantity",
+		},
+	}, config.DefinitionTypeOverrides())
+	assert.Equal(t, []string{"github.com/go-openapi/strfmt", "time", "example.com/quantity"}, config.Dependencies())
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join("testdata", "invalid.yaml")
+	_, err := Load(path)
+	require.Error(t, err)
+
+	var validationError *ValidationError
+	require.ErrorAs(t, err, &validationError)
+	assert.Equal(t, path, validationError.Path)
+	assert.Equal(t, []string{
+		"version: unsupported version 2, it must be 1",
+		"inputs.download.retries: must not be negative",
+		"inputs.download.timeout: 'soon' is not a positive duration, like `30s`",
+		"output.moduleDir: cannot be used together with output.dir",
+		"output.moduleDir: cannot be used together with output.layout",
+		"output.moduleDir: cannot be used together with the go.mod settings of module",
+		"output.layout: unknown layout 'flat', it must be either gopath or module",
+		"output.overlays[0]: dir must be set",
+		"output.overlays[0]: unknown overlay policy 'merge', it must be one of replace, add or fail",
+		"module.go: invalid go version '1.x': must match format 1.23.0",
+		"module.toolchain: invalid toolchain version '1.24': must match format go1.23.0 or default",
+		"module.require[0]: usage: require module/path v1.2.3",
+		`module.replace[0]: replace github.com/kubewarden/strfmt: version "latest" invalid: must be of the form v1.2.3`,
+		"selection.include[0]: invalid selector 'apps/v1': it must start with one of package:, gv: or gvk:",
+		"packageMapping: package mapping rule #1: either prefix or regexp must be set",
+		"initialisms[0]: 'H-PA' must be made of letters and digits",
+		"patches[0]: invalid extension 'omitempty': the name must start with 'x-'",
+		"typeOverrides[0]: type 'time.Time' is not a valid Go identifier",
+		"typeOverrides[1]: format 'date-time' is already overridden",
+		"typeOverrides[1]: import.alias cannot be set without import.package",
+		"typeOverrides[2]: either format or definition must be set",
+		"typeOverrides[3]: format and definition cannot be used at the same time",
+		"typeOverrides[4]: import.package must be set when overriding a definition",
+		"typeOverrides[4]: marshalJSON can be used only together with format",
+		"typeOverrides[5]: definition 'io.k8s.apimachinery.pkg.api.resource.Quantity' is already overridden",
+	}, validationError.Problems)
+	assert.Contains(t, err.Error(), "invalid configuration file "+path+":\n  - version: unsupported version 2")
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	_, err := Load(filepath.Join("testdata", "unknown_field.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field directory not found")
+}
+
+func TestDefaultGoModule(t *testing.T) {
+	config := Config{Version: CurrentVersion}
+	assert.Equal(t, split.DefaultGoModule(), config.GoModule())
+
+	config.Module.Go = "1.24"
+	goModule := config.GoModule()
+	assert.Equal(t, "1.24", goModule.Go)
+	assert.Equal(t, split.DefaultGoModule().Replace, goModule.Replace)
+}
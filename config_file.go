@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubewarden/k8s-objects-generator/config"
+)
+
+// inputFlagNames are the flags selecting the inputs, see inputFlags.
+var inputFlagNames = []string{"f", "openapi-v3", "kube-version", "crd", "from-cluster"} //nolint:gochecknoglobals // this is a constant list
+
+// configFlags holds the flag selecting the configuration file.
+type configFlags struct {
+	// path of the configuration file, set by apply when the default one is
+	// used
+	path string
+}
+
+func (c *configFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&c.path, "config", "",
+		"The configuration file. Defaults to `"+config.FileName+"` when it exists inside of the working directory. The flags override the values of the file")
+}
+
+// apply loads the configuration file and sets the flags that haven't been
+// specified on the command line to its values. An empty configuration is
+// returned when there's no configuration file.
+func (c *configFlags) apply(flags *flag.FlagSet) (*config.Config, error) {
+	path := c.path
+	if path == "" {
+		if _, err := os.Stat(config.FileName); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return &config.Config{Version: config.CurrentVersion}, nil
+			}
+			return nil, errors.Wrapf(err, "cannot access configuration file %s", config.FileName)
+		}
+		path = config.FileName
+		c.path = path
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		visited[f.Name] = true
+	})
+	// the output directory and the module path cannot be used together with
+	// the directory of an existing module, the command line wins
+	if visited["module-dir"] {
+		visited["o"], visited["repo"], visited["layout"] = true, true, true
+	}
+	if visited["o"] || visited["repo"] || visited["layout"] {
+		visited["module-dir"] = true
+	}
+	// the inputs of the command line replace all the ones of the file
+	if slices.ContainsFunc(inputFlagNames, func(name string) bool { return visited[name] }) {
+		for _, name := range inputFlagNames {
+			visited[name] = true
+		}
+	}
+
+	for _, value := range configFlagValues(cfg) {
+		if visited[value.name] || flags.Lookup(value.name) == nil {
+			continue
+		}
+		for _, v := range value.values {
+			if err := flags.Set(value.name, v); err != nil {
+				return nil, errors.Wrapf(err, "invalid value of -%s inside of configuration file %s", value.name, path)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// baseDir returns the directory the paths recorded by the manifest are
+// relative to: the one of the configuration file, empty when there's no
+// configuration file.
+func (c *configFlags) baseDir() (string, error) {
+	if c.path == "" {
+		return "", nil
+	}
+	dir, err := filepath.Abs(filepath.Dir(c.path))
+	return dir, errors.Wrapf(err, "cannot calculate absolute path of %s", c.path)
+}
+
+// configFlagValue is the value of a flag defined by the configuration file.
+// Flags that can be repeated have multiple values.
+type configFlagValue struct {
+	name   string
+	values []string
+}
+
+// configFlagValues returns the values of the flags defined by the
+// configuration, the unset ones are omitted.
+func configFlagValues(cfg *config.Config) []configFlagValue {
+	values := []configFlagValue{}
+	add := func(name string, value ...string) {
+		if len(value) > 0 && value[0] != "" {
+			values = append(values, configFlagValue{name: name, values: value})
+		}
+	}
+	addBool := func(name string, value bool) {
+		if value {
+			add(name, "true")
+		}
+	}
+
+	add("f", cfg.Inputs.SwaggerFiles...)
+	add("openapi-v3", cfg.Inputs.OpenAPIv3)
+	add("kube-version", cfg.Inputs.KubernetesVersion)
+	add("crd", cfg.Inputs.CRDs...)
+	if cluster := cfg.Inputs.Cluster; cluster != nil {
+		addBool("from-cluster", true)
+		add("kubeconfig", cluster.Kubeconfig)
+		add("kube-context", cluster.Context)
+		addBool("cluster-openapi-v3", cluster.OpenAPIv3)
+	}
+	if download := cfg.Inputs.Download; download != nil {
+		add("cache-dir", download.CacheDir)
+		addBool("offline", download.Offline)
+		add("swagger-url", download.SwaggerURLs...)
+		if download.Retries != nil {
+			add("download-retries", strconv.Itoa(*download.Retries))
+		}
+		add("download-timeout", download.Timeout)
+		add("swagger-lock", download.LockFile)
+	}
+
+	add("o", cfg.Output.Dir)
+	add("layout", cfg.Output.Layout)
+	add("module-dir", cfg.Output.ModuleDir)
+	if cfg.Output.Jobs > 0 {
+		add("j", strconv.Itoa(cfg.Output.Jobs))
+	}
+	if cfg.Output.ModelCache != nil {
+		addBool("no-model-cache", !*cfg.Output.ModelCache)
+	}
+	if cfg.Output.Verify != nil {
+		addBool("no-verify", !*cfg.Output.Verify)
+	}
+	add("repo", cfg.Module.Path)
+
+	add("include", cfg.Selection.Include...)
+	add("exclude", cfg.Selection.Exclude...)
+
+	return values
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFileInputs(t *testing.T) {
+	configDir := t.TempDir()
+	configFileName := filepath.Join(configDir, "k8s-objects-generator.yaml")
+	require.NoError(t, os.WriteFile(configFileName, []byte(`version: 1
+inputs:
+  kubernetesVersion: "1.33"
+  crds:
+    - crds
+output:
+  dir: out
+`), 0o600))
+
+	tests := []struct {
+		name                string
+		args                []string
+		expectedSwaggerFile []string
+		expectedKubeVersion string
+		expectedCRDs        []string
+		expectedCluster     bool
+	}{
+		{
+			name:                "inputs of the file",
+			expectedKubeVersion: "1.33",
+			expectedCRDs:        []string{filepath.Join(configDir, "crds")},
+		},
+		{
+			name:                "swagger file replacing the inputs of the file",
+			args:                []string{"-f", "swagger.json"},
+			expectedSwaggerFile: []string{"swagger.json"},
+		},
+		{
+			name:         "CRDs replacing the inputs of the file",
+			args:         []string{"-crd", "other-crds"},
+			expectedCRDs: []string{"other-crds"},
+		},
+		{
+			name:            "cluster replacing the inputs of the file",
+			args:            []string{"-from-cluster"},
+			expectedCluster: true,
+		},
+		{
+			name:                "Kubernetes version replacing the one of the file",
+			args:                []string{"-kube-version", "1.32"},
+			expectedKubeVersion: "1.32",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			var inputs inputFlags
+			var outputDir string
+			inputs.register(flags)
+			flags.StringVar(&outputDir, "o", "", "")
+			require.NoError(t, flags.Parse(test.args))
+
+			configFile := configFlags{path: configFileName}
+			_, err := configFile.apply(flags)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedSwaggerFile, []string(inputs.swaggerFiles))
+			assert.Equal(t, test.expectedKubeVersion, inputs.kubeVersion)
+			assert.Equal(t, test.expectedCRDs, []string(inputs.crdPaths))
+			assert.Equal(t, test.expectedCluster, inputs.cluster.enabled)
+			// the other settings of the file are kept
+			assert.Equal(t, filepath.Join(configDir, "out"), outputDir)
+		})
+	}
+}
@@ -1,55 +1,58 @@
 package main
 
 import (
-	"fmt"
-	"io"
-	"log/slog"
-	"net/http"
+	"flag"
+	"time"
 
-	"github.com/blang/semver/v4"
-	"github.com/pkg/errors"
+	"github.com/kubewarden/k8s-objects-generator/input"
 )
 
-type SwaggerData struct {
-	Data              []byte
-	KubernetesVersion string
+// downloadFlags holds the flags that control how the swagger files of
+// Kubernetes are fetched.
+type downloadFlags struct {
+	cacheDir     string
+	offline      bool
+	urlTemplates stringSliceFlag
+	retries      int
+	timeout      time.Duration
+	lockFile     string
+	// readOnly prevents the downloaded files from being cached
+	readOnly bool
 }
 
-// DownloadSwagger downloads the swagger file for the Kubernetes version
-// specified by the user.
-func DownloadSwagger(kubeVersion string) (*SwaggerData, error) {
-	version, err := semver.ParseTolerant(kubeVersion)
-	if err != nil {
-		return nil, errors.Wrapf(err, "cannot parse kubernetes version %s", kubeVersion)
-	}
-
-	downloadURL := fmt.Sprintf(
-		"https://github.com/kubernetes/kubernetes/raw/v%d.%d.%d/api/openapi-spec/swagger.json",
-		version.Major, version.Minor, version.Patch)
-
-	slog.Info("Downloading swagger file for Kubernetes", "version", version.String(), "downloadURL", downloadURL)
+func (d *downloadFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&d.cacheDir, "cache-dir", "", "The directory where the downloaded swagger files and the generated models are cached. Defaults to the user cache directory")
+	flags.BoolVar(&d.offline, "offline", false, "Never download the swagger file, fail if the version requested with `-kube-version` is not cached")
+	flags.Var(&d.urlTemplates, "swagger-url",
+		"Template of the URL of the swagger file, `{{ .Version }}`, `{{ .Ref }}`, `{{ .Major }}`, `{{ .Minor }}` and `{{ .Patch }}` are replaced with the Kubernetes version. "+
+			"Both http(s):// and file:// URLs are supported. Can be repeated, the URLs are tried in order. Defaults to "+input.DefaultSwaggerURLTemplate)
+	flags.IntVar(&d.retries, "download-retries", input.DefaultDownloadRetries, "Number of times a failed download is retried")
+	flags.DurationVar(&d.timeout, "download-timeout", input.DefaultDownloadTimeout, "Timeout of each download attempt")
+	flags.StringVar(&d.lockFile, "swagger-lock", "", "File holding the expected SHA-256 digests of the swagger files, generation is aborted on mismatch")
+}
 
-	resp, err := http.Get(downloadURL) //nolint:gosec,noctx // let's keep the code simple, we just do 1 request..
+// newDownloader returns the downloader configured by the flags.
+func (d *downloadFlags) newDownloader() (*input.SwaggerDownloader, error) {
+	cache, err := input.NewSwaggerCache(d.cacheDir)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Cannot fetch swagger file from %s", downloadURL)
+		return nil, err
 	}
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			slog.Info("failed to close response body", "error", cerr)
-		}
-	}()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Wrapf(err, "Cannot read contents of response from %s", downloadURL)
+	downloader := input.NewSwaggerDownloader(cache)
+	downloader.Offline = d.offline
+	downloader.ReadOnly = d.readOnly
+	downloader.Retries = d.retries
+	downloader.Timeout = d.timeout
+	if len(d.urlTemplates) > 0 {
+		downloader.URLTemplates = d.urlTemplates
 	}
-
-	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return nil, fmt.Errorf("response failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	if d.lockFile != "" {
+		lock, err := input.LoadSwaggerLock(d.lockFile)
+		if err != nil {
+			return nil, err
+		}
+		downloader.Lock = lock
 	}
 
-	return &SwaggerData{
-		Data:              body,
-		KubernetesVersion: version.String(),
-	}, nil
+	return downloader, nil
 }
@@ -0,0 +1,184 @@
+package emitter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDefinitions(t *testing.T) openapi_spec.Definitions {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "swagger.json"))
+	require.NoError(t, err)
+
+	swagger := openapi_spec.Swagger{}
+	require.NoError(t, swagger.UnmarshalJSON(data))
+
+	return swagger.Definitions
+}
+
+func TestGenerate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	emitter := NewEmitter(fs, Options{})
+
+	err := emitter.Generate("/out", "v1", loadDefinitions(t))
+	require.NoError(t, err)
+
+	files, err := afero.Glob(fs, "/out/*")
+	require.NoError(t, err)
+	// the interfaces Blob and Empty are not generated
+	assert.ElementsMatch(t, []string{
+		"/out/c_e_l_selector.go",
+		"/out/child.go",
+		"/out/count.go",
+		"/out/micro_time.go",
+		"/out/pod_linux_swagger.go",
+		"/out/quantity.go",
+		"/out/tags.go",
+		"/out/time.go",
+		"/out/widget.go",
+	}, files)
+
+	// the gold files have been generated by go-swagger
+	for _, file := range files {
+		expected, err := os.ReadFile(filepath.Join("testdata", filepath.Base(file)+".gold"))
+		require.NoError(t, err)
+
+		actual, err := afero.ReadFile(fs, file)
+		require.NoError(t, err)
+
+		assert.Equal(t, string(expected), string(actual), "unexpected content of %s", file)
+	}
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{
+			name:   "allOf",
+			schema: `{"type": "object", "properties": {"child": {"allOf": [{"$ref": "#/definitions/Child"}]}}}`,
+		},
+		{
+			name:   "inline object",
+			schema: `{"type": "object", "properties": {"child": {"type": "object", "properties": {"name": {"type": "string"}}}}}`,
+		},
+		{
+			name:   "properties and additionalProperties",
+			schema: `{"type": "object", "properties": {"name": {"type": "string"}}, "additionalProperties": {"type": "string"}}`,
+		},
+		{
+			name:   "tuple",
+			schema: `{"type": "object", "properties": {"pair": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]}}}`,
+		},
+		{
+			name:   "unknown reference",
+			schema: `{"type": "object", "properties": {"child": {"$ref": "#/definitions/Unknown"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := openapi_spec.Schema{}
+			require.NoError(t, schema.UnmarshalJSON([]byte(tt.schema)))
+
+			definitions := openapi_spec.Definitions{
+				"Parent": schema,
+				"Child":  {SchemaProps: openapi_spec.SchemaProps{Type: openapi_spec.StringOrArray{"string"}}},
+			}
+
+			_, err := NewEmitter(afero.NewMemMapFs(), Options{}).Render("v1", "Parent", definitions)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	n := newNames()
+
+	tests := []struct {
+		name      string
+		goName    string
+		humanName string
+		fileName  string
+	}{
+		{name: "podIP", goName: "PodIP", humanName: "pod IP", fileName: "pod_ip"},
+		{name: "hpaCIDR", goName: "HPACIDR", humanName: "hpa CIDR", fileName: "hpa_cidr"},
+		{name: "$ref", goName: "DollarRef", humanName: "dollar ref", fileName: "dollar_ref"},
+		{name: "x-kubernetes-foo", goName: "XKubernetesFoo", humanName: "x kubernetes foo", fileName: "x_kubernetes_foo"},
+		{name: "PodLinux", goName: "PodLinux", humanName: "pod linux", fileName: "pod_linux_swagger"},
+		{name: "ServiceTest", goName: "ServiceTest", humanName: "service test", fileName: "service_test_swagger"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.goName, n.goName(tt.name))
+			assert.Equal(t, tt.humanName, n.humanName(tt.name))
+			assert.Equal(t, tt.fileName, n.fileName(tt.name))
+		})
+	}
+
+	assert.Equal(t, "jsonext", n.importAlias("encoding/json"))
+	assert.Equal(t, "core", n.importAlias("k8s.io/api/core"))
+}
+
+func TestRenderFormatTypes(t *testing.T) {
+	definitions := openapi_spec.Definitions{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"Time": {"type": "string", "format": "date-time"},
+		"Event": {
+			"type": "object",
+			"properties": {
+				"at": {"type": "string", "format": "date-time"},
+				"size": {"type": "string", "format": "quantity"}
+			}
+		}
+	}`), &definitions))
+
+	emitter := NewEmitter(afero.NewMemMapFs(), Options{
+		FormatTypes: []FormatType{
+			{Format: "date-time", Package: "time", Type: "Time", JSONMarshaler: true},
+			{Format: "quantity", Package: "example.com/units", Alias: "units", Type: "Quantity"},
+		},
+	})
+
+	source, err := emitter.Render("v1", "Time", definitions)
+	require.NoError(t, err)
+	// the packages of the standard library are imported with the `ext` suffix
+	assert.Contains(t, string(source), "timeext \"time\"")
+	assert.Contains(t, string(source), "type Time timeext.Time\n")
+	assert.Contains(t, string(source), "func (m Time) MarshalJSON() ([]byte, error) {")
+
+	source, err = emitter.Render("v1", "Event", definitions)
+	require.NoError(t, err)
+	assert.Contains(t, string(source), "\"example.com/units\"")
+	assert.Contains(t, string(source), "At timeext.Time `json:\"at,omitempty\"`")
+	assert.Contains(t, string(source), "Size units.Quantity `json:\"size,omitempty\"`")
+	assert.NotContains(t, string(source), "strfmt")
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := NewEmitter(afero.NewMemMapFs(), Options{}).Fingerprint()
+
+	assert.Equal(t, fingerprint, NewEmitter(afero.NewMemMapFs(), Options{}).Fingerprint())
+	assert.NotEqual(t, fingerprint, NewEmitter(afero.NewMemMapFs(), Options{
+		FormatTypes: []FormatType{{Format: "date-time", Package: "time", Type: "Time"}},
+	}).Fingerprint())
+}
+
+func TestNamesInitialismsAreNotShared(t *testing.T) {
+	custom := newNames("VPA")
+	assert.Equal(t, "VPAScalingRules", custom.goName("vpaScalingRules"))
+	assert.Equal(t, "IPV6Address", custom.goName("ipv6Address"))
+
+	// the initialisms of an emitter don't leak into the other ones
+	assert.Equal(t, "VpaScalingRules", newNames().goName("vpaScalingRules"))
+}
@@ -0,0 +1,124 @@
+package emitter
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/go-openapi/swag/mangling"
+)
+
+// additionalInitialisms are the initialisms used by the Kubernetes types on
+// top of the common ones, e.g. `HPA` leads to `HPAScalingRules` instead of
+// `HpaScalingRules`.
+//
+//nolint:gochecknoglobals // this is a constant list
+var additionalInitialisms = []string{"HPA", "AWS", "CSI", "FS", "FC", "GCE", "GRPC", "ISCSI", "NFS", "OS", "RBD", "SE", "IO", "CIDR"}
+
+// reservedFileSuffixes are the file name suffixes that are meaningful to
+// `go build`, like `_linux` or `_test`.
+//
+//nolint:gochecknoglobals // this is a constant list
+var reservedFileSuffixes = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+	"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "windows": true, "zos": true,
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true, "arm64be": true,
+	"loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true,
+	"s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+	"test": true,
+}
+
+// conflictingPackageNames are the package names that cannot be used as import
+// alias, they get an `ext` suffix: `encoding/json` is imported as `jsonext`.
+//
+//nolint:gochecknoglobals // this is a constant list
+var conflictingPackageNames = map[string]bool{
+	"api": true, "httptransport": true, "formats": true, "server": true,
+	"errors": true, "runtime": true, "middleware": true, "security": true, "spec": true, "strfmt": true,
+	"loads": true, "swag": true, "validate": true,
+	"tls": true, "http": true, "fmt": true, "strings": true, "log": true, "flags": true, "pflag": true,
+	"json": true, "time": true,
+}
+
+// names turns the names found inside of the swagger definitions into Go
+// identifiers, comments and file names. The names are the same ones produced
+// by go-swagger, which relies on the name mangling of swag too.
+type names struct {
+	mangler mangling.NameMangler
+}
+
+// newNames returns the names, the given initialisms are added to the
+// built-in ones. They are used only by these names.
+//
+// Like go-swagger, all the initialisms are upper cased: `ipv6Address` leads to
+// `IPV6Address`, not `IPv6Address`.
+func newNames(initialisms ...string) names {
+	words := slices.Concat(mangling.DefaultInitialisms(), additionalInitialisms, initialisms)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word)
+	}
+	return names{
+		mangler: mangling.NewNameMangler(mangling.WithInitialisms(words...)),
+	}
+}
+
+// goName returns the exported Go identifier of a type or of a field:
+// `ipv6Address` -> `IPV6Address`.
+func (n names) goName(name string) string {
+	runes := []rune(name)
+	switch {
+	case len(runes) == 0:
+		return "Empty"
+	case len(runes) == 1 && strings.ContainsRune("+-#_*/=", runes[0]):
+		// single symbols are not handled by swag
+		return prefixForName(runes[0])
+	}
+	return n.mangler.ToGoName(n.mangler.ToGoName(name))
+}
+
+// humanName returns the lower case words making the name, it's used when
+// there's no description: `podIP` -> `pod IP`.
+func (n names) humanName(name string) string {
+	return n.mangler.ToHumanNameLower(name)
+}
+
+// fileName returns the name of the file holding the given type, without
+// extension. Names that would be interpreted as build constraints get a
+// `_swagger` suffix: `PodLinux` -> `pod_linux_swagger`.
+func (n names) fileName(name string) string {
+	parts := strings.Split(n.mangler.ToFileName(name), "_")
+	if reservedFileSuffixes[parts[len(parts)-1]] {
+		parts = append(parts, "swagger")
+	}
+	return strings.Join(parts, "_")
+}
+
+// importAlias returns the alias used when the import doesn't provide one.
+func (n names) importAlias(importPath string) string {
+	alias := n.mangler.ToFileName(path.Base(importPath))
+	if conflictingPackageNames[alias] {
+		alias += "ext"
+	}
+	return alias
+}
+
+func prefixForName(r rune) string {
+	switch r {
+	case '+':
+		return "Plus"
+	case '-':
+		return "Minus"
+	case '#':
+		return "HashTag"
+	case '*':
+		return "Asterisk"
+	case '/':
+		return "ForwardSlash"
+	case '=':
+		return "EqualSign"
+	default:
+		return "Nr"
+	}
+}
@@ -0,0 +1,555 @@
+package emitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+const (
+	definitionsRefPrefix = "#/definitions/"
+
+	typeArray   = "array"
+	typeObject  = "object"
+	typeString  = "string"
+	typeInteger = "integer"
+	typeNumber  = "number"
+	typeBoolean = "boolean"
+
+	goInterface = "interface{}"
+
+	xGoType       = "x-go-type"
+	xGoName       = "x-go-name"
+	xGoJSONString = "x-go-json-string"
+	xGoCustomTag  = "x-go-custom-tag"
+	xOmitEmpty    = "x-omitempty"
+	xNullable     = "x-nullable"
+	xIsNullable   = "x-isnullable"
+	xOrder        = "x-order"
+)
+
+// typeMapping maps the swagger types to Go types.
+//
+//nolint:gochecknoglobals // this is a constant list
+var typeMapping = map[string]string{
+	typeString:  "string",
+	typeBoolean: "bool",
+	typeInteger: "int64",
+	typeNumber:  "float64",
+}
+
+// formatMapping maps the swagger formats to Go types, the dashes of the
+// formats are stripped before the lookup.
+//
+//nolint:gochecknoglobals // this is a constant list
+var formatMapping = map[string]map[string]string{
+	typeNumber: {
+		"double": "float64",
+		"float":  "float32",
+		"int":    "int64",
+		"int8":   "int8",
+		"int16":  "int16",
+		"int32":  "int32",
+		"int64":  "int64",
+		"uint":   "uint64",
+		"uint8":  "uint8",
+		"uint16": "uint16",
+		"uint32": "uint32",
+		"uint64": "uint64",
+	},
+	typeInteger: {
+		"int":    "int64",
+		"int8":   "int8",
+		"int16":  "int16",
+		"int32":  "int32",
+		"int64":  "int64",
+		"uint":   "uint64",
+		"uint8":  "uint8",
+		"uint16": "uint16",
+		"uint32": "uint32",
+		"uint64": "uint64",
+	},
+	typeString: {
+		"char":         "rune",
+		"byte":         "strfmt.Base64",
+		"creditcard":   "strfmt.CreditCard",
+		"date":         "strfmt.Date",
+		"datetime":     "strfmt.DateTime",
+		"duration":     "strfmt.Duration",
+		"email":        "strfmt.Email",
+		"hexcolor":     "strfmt.HexColor",
+		"hostname":     "strfmt.Hostname",
+		"ipv4":         "strfmt.IPv4",
+		"ipv6":         "strfmt.IPv6",
+		"isbn":         "strfmt.ISBN",
+		"isbn10":       "strfmt.ISBN10",
+		"isbn13":       "strfmt.ISBN13",
+		"mac":          "strfmt.MAC",
+		"bsonobjectid": "strfmt.ObjectId",
+		"objectid":     "strfmt.ObjectId",
+		"ObjectId":     "strfmt.ObjectId",
+		"password":     "strfmt.Password",
+		"rgbcolor":     "strfmt.RGBColor",
+		"ssn":          "strfmt.SSN",
+		"uri":          "strfmt.URI",
+		"uuid":         "strfmt.UUID",
+		"uuid3":        "strfmt.UUID3",
+		"uuid4":        "strfmt.UUID4",
+		"uuid5":        "strfmt.UUID5",
+		"ulid":         "strfmt.ULID",
+	},
+}
+
+// jsonMarshalers are the strfmt types that are structs or byte slices, the
+// named types built on top of them must forward the JSON (un)marshalling to
+// them.
+//
+//nolint:gochecknoglobals // this is a constant list
+var jsonMarshalers = map[string]bool{
+	"strfmt.Base64":   true,
+	"strfmt.Date":     true,
+	"strfmt.DateTime": true,
+	"strfmt.Duration": true,
+	"strfmt.ObjectId": true,
+}
+
+const strfmtPackage = "github.com/go-openapi/strfmt"
+
+// Dependencies returns the packages imported by the generated files, besides
+// the standard library and the generated packages. The packages of the
+// format types given to the emitter are not part of them.
+func Dependencies() []string {
+	return []string{strfmtPackage}
+}
+
+// resolvedType describes the Go type of a schema.
+type resolvedType struct {
+	goType string
+	// swaggerFormat is the format of the schema, it's documented when the
+	// type is provided by strfmt
+	swaggerFormat     string
+	isCustomFormatter bool
+	nullable          bool
+	isArray           bool
+	isMap             bool
+	emptyOmitted      bool
+	jsonString        bool
+	customTag         string
+	// jsonMarshaler is true when the types declared on top of this one must
+	// forward the JSON encoding to it
+	jsonMarshaler bool
+}
+
+// typeResolver computes the Go types of the schemas found inside of the
+// definitions of a package. The imports required by the resolved types are
+// collected while resolving them.
+type typeResolver struct {
+	definitions openapi_spec.Definitions
+	names       names
+	formatTypes map[string]FormatType
+	imports     map[string]string
+	// ids of the definitions being resolved, used to detect cycles
+	resolving map[string]bool
+}
+
+func newTypeResolver(definitions openapi_spec.Definitions, n names, formatTypes map[string]FormatType) *typeResolver {
+	return &typeResolver{
+		definitions: definitions,
+		names:       n,
+		formatTypes: formatTypes,
+		imports:     make(map[string]string),
+		resolving:   make(map[string]bool),
+	}
+}
+
+// resolve returns the Go type of the schema. `aliased` must be true when the
+// schema is a definition, `required` when the schema is a required property.
+func (r *typeResolver) resolve(schema *openapi_spec.Schema, aliased, required bool) (resolvedType, error) {
+	if _, external := schema.Extensions[xGoType]; external {
+		return r.resolveExternal(schema, required)
+	}
+
+	if schema.Ref.String() != "" {
+		return r.resolveRef(schema, required)
+	}
+
+	if len(schema.AllOf) > 0 {
+		return resolvedType{}, errors.New("allOf is not supported")
+	}
+
+	tpe := typeObject
+	if len(schema.Type) > 0 && schema.Type[0] != "" {
+		tpe = schema.Type[0]
+	}
+
+	result, err := r.resolveType(schema, tpe, aliased, required)
+	if err != nil {
+		return resolvedType{}, err
+	}
+	result.setExtensions(schema, tpe, aliased)
+
+	return result, nil
+}
+
+func (r *typeResolver) resolveType(schema *openapi_spec.Schema, tpe string, aliased, required bool) (resolvedType, error) {
+	if result, found := r.resolveFormat(schema, required); found {
+		return result, nil
+	}
+
+	switch tpe {
+	case typeArray:
+		return r.resolveArray(schema)
+	case typeObject:
+		return r.resolveObject(schema, aliased)
+	case typeString:
+		return resolvedType{
+			goType:   typeMapping[tpe],
+			nullable: nullableString(schema, required),
+		}, nil
+	case typeInteger, typeNumber:
+		return resolvedType{
+			goType:   typeMapping[tpe],
+			nullable: nullableNumber(schema, required),
+		}, nil
+	case typeBoolean:
+		return resolvedType{
+			goType:   typeMapping[tpe],
+			nullable: nullableBool(schema, required),
+		}, nil
+	default:
+		return resolvedType{}, fmt.Errorf("unsupported type %q", tpe)
+	}
+}
+
+// resolveExternal handles the schemas declaring their Go type via the
+// `x-go-type` extension, like the references to other packages.
+func (r *typeResolver) resolveExternal(schema *openapi_spec.Schema, required bool) (resolvedType, error) {
+	goType, err := r.externalGoType(schema)
+	if err != nil {
+		return resolvedType{}, err
+	}
+
+	result := resolvedType{
+		goType:   goType,
+		nullable: required,
+	}
+	if nullable, found := nullableOverride(schema); found {
+		result.nullable = nullable
+	}
+	result.setExtensions(schema, typeObject, false)
+
+	return result, nil
+}
+
+// goTypeExtension is the value of the `x-go-type` extension.
+type goTypeExtension struct {
+	Import struct {
+		Package string `json:"package"`
+		Alias   string `json:"alias"`
+	} `json:"import"`
+	Type string `json:"type"`
+}
+
+func (r *typeResolver) externalGoType(schema *openapi_spec.Schema) (string, error) {
+	// the extension is either decoded from JSON or built by the swaggerhelpers
+	// package, round trip it to handle both
+	data, err := json.Marshal(schema.Extensions[xGoType])
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot encode %s", xGoType)
+	}
+	goType := goTypeExtension{}
+	if err = json.Unmarshal(data, &goType); err != nil {
+		return "", errors.Wrapf(err, "cannot decode %s", xGoType)
+	}
+
+	if goType.Type == "" {
+		return "", fmt.Errorf("%s doesn't define a type", xGoType)
+	}
+	if goType.Import.Package == "" {
+		return goType.Type, nil
+	}
+
+	alias := goType.Import.Alias
+	if alias == "" {
+		alias = r.names.importAlias(goType.Import.Package)
+	}
+	r.imports[goType.Import.Package] = alias
+
+	return alias + "." + goType.Type, nil
+}
+
+// resolveRef handles the references to the definitions of the same package.
+func (r *typeResolver) resolveRef(schema *openapi_spec.Schema, required bool) (resolvedType, error) {
+	ref := schema.Ref.String()
+	if !strings.HasPrefix(ref, definitionsRefPrefix) {
+		return resolvedType{}, fmt.Errorf("unsupported reference %s", ref)
+	}
+	name := strings.TrimPrefix(ref, definitionsRefPrefix)
+
+	target, found := r.definitions[name]
+	if !found {
+		return resolvedType{}, fmt.Errorf("cannot resolve reference %s", ref)
+	}
+	if r.resolving[name] {
+		return resolvedType{}, fmt.Errorf("definition %s references itself", name)
+	}
+
+	imports := maps.Clone(r.imports)
+	r.resolving[name] = true
+	result, err := r.resolve(&target, true, required)
+	delete(r.resolving, name)
+	if err != nil {
+		return resolvedType{}, errors.Wrapf(err, "cannot resolve reference %s", ref)
+	}
+
+	// interfaces are not generated, they are replaced by their external type
+	if _, external := target.Extensions[xGoType]; !external {
+		// the type is declared by this package, the imports needed by its
+		// definition are not needed here
+		r.imports = imports
+		result.goType = r.names.goName(name)
+	}
+	result.nullable = result.nullable || isNullable(&target)
+
+	return result, nil
+}
+
+func (r *typeResolver) resolveFormat(schema *openapi_spec.Schema, required bool) (resolvedType, bool) {
+	if schema.Format == "" {
+		return resolvedType{}, false
+	}
+
+	tpe := typeString
+	if len(schema.Type) > 0 {
+		tpe = schema.Type[0]
+	}
+
+	var result resolvedType
+	if formatType, found := r.formatTypes[schema.Format]; found {
+		result = resolvedType{
+			goType:        r.formatGoType(formatType),
+			swaggerFormat: schema.Format,
+			jsonMarshaler: formatType.JSONMarshaler,
+		}
+	} else {
+		format := strings.ReplaceAll(schema.Format, "-", "")
+		goType, found := formatMapping[tpe][format]
+		if !found {
+			goType, found = typeMapping[format]
+		}
+		if !found {
+			return resolvedType{}, false
+		}
+
+		result = resolvedType{
+			goType:            goType,
+			swaggerFormat:     schema.Format,
+			isCustomFormatter: strings.HasPrefix(goType, "strfmt."),
+		}
+		if result.isCustomFormatter {
+			r.imports[strfmtPackage] = "strfmt"
+		}
+	}
+
+	switch tpe {
+	case typeString:
+		result.nullable = nullableStrfmt(schema, required)
+	case typeNumber, typeInteger:
+		result.nullable = nullableNumber(schema, required)
+	default:
+		result.nullable = isNullable(schema)
+	}
+
+	return result, true
+}
+
+// formatGoType returns the Go type configured for a format, importing its
+// package.
+func (r *typeResolver) formatGoType(formatType FormatType) string {
+	if formatType.Package == "" {
+		return formatType.Type
+	}
+
+	alias := formatType.Alias
+	if alias == "" {
+		alias = r.names.importAlias(formatType.Package)
+	}
+	r.imports[formatType.Package] = alias
+
+	return alias + "." + formatType.Type
+}
+
+func (r *typeResolver) resolveArray(schema *openapi_spec.Schema) (resolvedType, error) {
+	result := resolvedType{isArray: true}
+
+	if schema.Items == nil || (schema.Items.Schema == nil && len(schema.Items.Schemas) == 0) {
+		result.goType = "[]" + goInterface
+		return result, nil
+	}
+	if schema.Items.Schema == nil {
+		return resolvedType{}, errors.New("tuples are not supported")
+	}
+
+	items, err := r.resolve(schema.Items.Schema, false, false)
+	if err != nil {
+		return resolvedType{}, err
+	}
+
+	// only the complex elements are nullable, unless forced by x-nullable
+	element := schema.Items.Schema
+	if name := strings.TrimPrefix(element.Ref.String(), definitionsRefPrefix); name != "" {
+		target := r.definitions[name]
+		element = &target
+	}
+	nullable, found := nullableOverride(element)
+	if !found {
+		nullable = len(element.Properties) > 0
+	}
+
+	if nullable && !strings.HasPrefix(items.goType, "*") {
+		result.goType = "[]*" + items.goType
+	} else {
+		result.goType = "[]" + items.goType
+	}
+
+	return result, nil
+}
+
+func (r *typeResolver) resolveObject(schema *openapi_spec.Schema, aliased bool) (resolvedType, error) {
+	hasAdditionalProperties := schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil
+
+	if len(schema.Properties) > 0 {
+		switch {
+		case hasAdditionalProperties:
+			return resolvedType{}, errors.New("objects with both properties and additionalProperties are not supported")
+		case !aliased:
+			return resolvedType{}, errors.New("inline object definitions are not supported")
+		}
+		// a struct, its name is set by the caller
+		return resolvedType{nullable: isNullable(schema)}, nil
+	}
+
+	if hasAdditionalProperties {
+		element := schema.AdditionalProperties.Schema
+		value, err := r.resolve(element, false, false)
+		if err != nil {
+			return resolvedType{}, err
+		}
+
+		// only complex map elements are nullable, unless forced by x-nullable
+		if !value.isArray && isNullable(element) {
+			return resolvedType{goType: "map[string]*" + value.goType, isMap: true}, nil
+		}
+		return resolvedType{goType: "map[string]" + value.goType, isMap: true}, nil
+	}
+
+	// an object without properties is rendered as interface{}, unless the
+	// number of its properties is validated
+	if schema.MinProperties != nil || schema.MaxProperties != nil {
+		return resolvedType{goType: "map[string]" + goInterface, isMap: true}, nil
+	}
+	return resolvedType{goType: goInterface, isMap: true}, nil
+}
+
+func (t *resolvedType) setExtensions(schema *openapi_spec.Schema, tpe string, aliased bool) {
+	if value, found := schema.Extensions[xOmitEmpty]; found {
+		omitted, isBool := value.(bool)
+		t.emptyOmitted = omitted && isBool
+	} else {
+		// arrays of primitives are not omitted when empty, unless they are
+		// a definition
+		t.emptyOmitted = tpe != typeArray || aliased
+	}
+
+	_, t.jsonString = schema.Extensions[xGoJSONString]
+
+	if customTag, found := schema.Extensions[xGoCustomTag]; found {
+		t.customTag, _ = customTag.(string)
+	}
+}
+
+// nullableOverride returns the nullability forced by the `x-isnullable` or
+// `x-nullable` extensions.
+func nullableOverride(schema *openapi_spec.Schema) (bool, bool) {
+	for _, extension := range []string{xIsNullable, xNullable} {
+		if nullable, isBool := schema.Extensions[extension].(bool); isBool {
+			return nullable, true
+		}
+	}
+	return false, false
+}
+
+func isNullable(schema *openapi_spec.Schema) bool {
+	if nullable, found := nullableOverride(schema); found {
+		return nullable
+	}
+	return len(schema.Properties) > 0 || len(schema.AllOf) > 0
+}
+
+// nullableBool makes a boolean a pointer when its zero value must be told
+// apart from no value: required properties and properties with a default.
+func nullableBool(schema *openapi_spec.Schema, required bool) bool {
+	if nullable, found := nullableOverride(schema); found {
+		return nullable
+	}
+	if required {
+		return schema.Default == nil && !schema.ReadOnly
+	}
+	return schema.Default != nil || schema.ReadOnly
+}
+
+// nullableNumber makes a number a pointer when its zero value must be told
+// apart from no value, or when zero is a valid value because of the minimum
+// and maximum validations.
+func nullableNumber(schema *openapi_spec.Schema, required bool) bool {
+	if nullable, found := nullableOverride(schema); found {
+		return nullable
+	}
+	hasDefault := schema.Default != nil && !isZero(schema.Default)
+
+	isMin := schema.Minimum != nil && (*schema.Minimum != 0 || schema.ExclusiveMinimum)
+	bcMin := schema.Minimum != nil && *schema.Minimum == 0 && !schema.ExclusiveMinimum
+	isMax := schema.Minimum == nil && (schema.Maximum != nil && (*schema.Maximum != 0 || schema.ExclusiveMaximum))
+	bcMax := schema.Maximum != nil && *schema.Maximum == 0 && !schema.ExclusiveMaximum
+	isMinMax := schema.Minimum != nil && schema.Maximum != nil && *schema.Minimum < *schema.Maximum
+	bcMinMax := schema.Minimum != nil && schema.Maximum != nil && (*schema.Minimum < 0 && 0 < *schema.Maximum)
+
+	return !schema.ReadOnly && (required || (hasDefault && !(isMin || isMax || isMinMax)) || bcMin || bcMax || bcMinMax)
+}
+
+// nullableString makes a string a pointer when its zero value must be told
+// apart from no value.
+func nullableString(schema *openapi_spec.Schema, required bool) bool {
+	if nullable, found := nullableOverride(schema); found {
+		return nullable
+	}
+	hasDefault := schema.Default != nil && !isZero(schema.Default)
+
+	isMin := schema.MinLength != nil && *schema.MinLength != 0
+	bcMin := schema.MinLength != nil && *schema.MinLength == 0
+
+	return !schema.ReadOnly && (required || (hasDefault && !isMin) || bcMin)
+}
+
+// nullableStrfmt makes a strfmt type a pointer when its zero value must be
+// told apart from no value.
+func nullableStrfmt(schema *openapi_spec.Schema, required bool) bool {
+	if nullable, found := nullableOverride(schema); found {
+		return nullable
+	}
+	hasDefault := schema.Default != nil && !isZero(schema.Default)
+
+	return !schema.ReadOnly && (required || hasDefault)
+}
+
+func isZero(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	return reflect.ValueOf(value).IsZero()
+}
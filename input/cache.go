@@ -0,0 +1,207 @@
+package input
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const cacheDirName = "k8s-objects-generator"
+
+// ErrNotCached is returned when the cache doesn't hold the requested entry.
+var ErrNotCached = errors.New("not found inside of the cache")
+
+// SwaggerCache is an on-disk cache of the swagger files downloaded from the
+// Kubernetes repository.
+//
+// The cache is content-addressed: the swagger files are stored by their
+// SHA-256 digest, while the version index maps each Kubernetes version to the
+// digest of its swagger file:
+//
+//	<root>/swagger/sha256/<digest>.json
+//	<root>/swagger/versions/<version>
+type SwaggerCache struct {
+	root string
+}
+
+// CachedSwagger describes an entry of the cache.
+type CachedSwagger struct {
+	KubernetesVersion string
+	Digest            string
+	Size              int64
+}
+
+// DefaultCacheDir returns the directory used to cache the downloaded files
+// when the user doesn't specify one. This is located inside of
+// `$XDG_CACHE_HOME`, or the equivalent directory of the operating system.
+func DefaultCacheDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "cannot find user cache directory")
+	}
+
+	return filepath.Join(userCacheDir, cacheDirName), nil
+}
+
+// NewSwaggerCache returns a cache stored inside of the `root` directory. The
+// default cache directory is used when `root` is empty.
+func NewSwaggerCache(root string) (*SwaggerCache, error) {
+	if root == "" {
+		defaultRoot, err := DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		root = defaultRoot
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot calculate absolute path of %s", root)
+	}
+
+	return &SwaggerCache{root: absRoot}, nil
+}
+
+// Root returns the directory holding the cache.
+func (c *SwaggerCache) Root() string {
+	return c.root
+}
+
+// Get returns the swagger file of the given Kubernetes version. ErrNotCached is
+// returned when the cache doesn't have it.
+func (c *SwaggerCache) Get(kubernetesVersion string) ([]byte, error) {
+	digest, err := os.ReadFile(c.versionFile(kubernetesVersion))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("swagger file of Kubernetes %s %w", kubernetesVersion, ErrNotCached)
+		}
+		return nil, errors.Wrapf(err, "cannot read cache index of Kubernetes %s", kubernetesVersion)
+	}
+
+	data, err := os.ReadFile(c.blobFile(string(digest)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("swagger file of Kubernetes %s %w", kubernetesVersion, ErrNotCached)
+		}
+		return nil, errors.Wrapf(err, "cannot read cached swagger file of Kubernetes %s", kubernetesVersion)
+	}
+
+	if actual := Digest(data); actual != string(digest) {
+		return nil, fmt.Errorf("cached swagger file of Kubernetes %s is corrupted: expected digest %s, got %s",
+			kubernetesVersion, digest, actual)
+	}
+
+	return data, nil
+}
+
+// Put stores the swagger file of the given Kubernetes version. The digest of
+// the file is returned.
+func (c *SwaggerCache) Put(kubernetesVersion string, data []byte) (string, error) {
+	digest := Digest(data)
+
+	if err := writeFileAtomically(c.blobFile(digest), data); err != nil {
+		return "", errors.Wrapf(err, "cannot cache swagger file of Kubernetes %s", kubernetesVersion)
+	}
+	if err := writeFileAtomically(c.versionFile(kubernetesVersion), []byte(digest)); err != nil {
+		return "", errors.Wrapf(err, "cannot update cache index of Kubernetes %s", kubernetesVersion)
+	}
+
+	return digest, nil
+}
+
+// List returns all the entries of the cache, sorted by Kubernetes version.
+func (c *SwaggerCache) List() ([]CachedSwagger, error) {
+	entries, err := os.ReadDir(c.versionsDir())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []CachedSwagger{}, nil
+		}
+		return nil, errors.Wrap(err, "cannot read cache index")
+	}
+
+	cached := []CachedSwagger{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		kubernetesVersion, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			// not created by the cache, ignore it
+			continue
+		}
+		digest, err := os.ReadFile(c.versionFile(kubernetesVersion))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read cache index of Kubernetes %s", kubernetesVersion)
+		}
+		info, err := os.Stat(c.blobFile(string(digest)))
+		if err != nil {
+			// the index points to a file that has been removed, ignore it
+			continue
+		}
+
+		cached = append(cached, CachedSwagger{
+			KubernetesVersion: kubernetesVersion,
+			Digest:            string(digest),
+			Size:              info.Size(),
+		})
+	}
+
+	sort.Slice(cached, func(i, j int) bool {
+		return cached[i].KubernetesVersion < cached[j].KubernetesVersion
+	})
+
+	return cached, nil
+}
+
+func (c *SwaggerCache) versionsDir() string {
+	return filepath.Join(c.root, "swagger", "versions")
+}
+
+// versionFile returns the index file of the Kubernetes version. The version
+// is escaped, since git refs can contain path separators.
+func (c *SwaggerCache) versionFile(kubernetesVersion string) string {
+	return filepath.Join(c.versionsDir(), url.PathEscape(kubernetesVersion))
+}
+
+func (c *SwaggerCache) blobFile(digest string) string {
+	return filepath.Join(c.root, "swagger", "sha256", digest+".json")
+}
+
+// Digest returns the hex encoded SHA-256 digest of the data.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomically writes the file using a temporary file that is then
+// renamed. Concurrent readers never see a partially written file.
+func writeFileAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name()) //nolint:errcheck // the file doesn't exist anymore once renamed
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,66 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwaggerCache(t *testing.T) {
+	cache, err := NewSwaggerCache(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = cache.Get("1.33.0")
+	require.ErrorIs(t, err, ErrNotCached)
+
+	data := []byte(`{"swagger": "2.0"}`)
+	digest, err := cache.Put("1.33.0", data)
+	require.NoError(t, err)
+	assert.Equal(t, Digest(data), digest)
+
+	// the same content is stored only once
+	_, err = cache.Put("1.32.0", data)
+	require.NoError(t, err)
+	blobs, err := os.ReadDir(filepath.Join(cache.Root(), "swagger", "sha256"))
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+
+	cached, err := cache.Get("1.33.0")
+	require.NoError(t, err)
+	assert.Equal(t, data, cached)
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Equal(t, []CachedSwagger{
+		{KubernetesVersion: "1.32.0", Digest: digest, Size: int64(len(data))},
+		{KubernetesVersion: "1.33.0", Digest: digest, Size: int64(len(data))},
+	}, entries)
+}
+
+func TestSwaggerCacheDetectsCorruption(t *testing.T) {
+	cache, err := NewSwaggerCache(t.TempDir())
+	require.NoError(t, err)
+
+	digest, err := cache.Put("1.33.0", []byte(`{"swagger": "2.0"}`))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(cache.blobFile(digest), []byte(`{"swagger": "2.`), 0o600))
+
+	_, err = cache.Get("1.33.0")
+	require.ErrorContains(t, err, "is corrupted")
+}
+
+func TestDefaultCacheDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	t.Setenv("HOME", "/home/user")
+
+	dir, err := DefaultCacheDir()
+	require.NoError(t, err)
+	assert.Contains(t, []string{
+		"/xdg/cache/k8s-objects-generator",                // Linux
+		"/home/user/Library/Caches/k8s-objects-generator", // macOS
+	}, dir)
+}
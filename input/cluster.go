@@ -0,0 +1,199 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	clusterVersionPath   = "/version"
+	clusterOpenAPIv2Path = "/openapi/v2"
+	clusterOpenAPIv3Path = "/openapi/v3"
+)
+
+// ClusterSource fetches the OpenAPI document served by the API server of a
+// live cluster. Unlike the upstream swagger file, the document includes the
+// types provided by CRDs and aggregated API servers.
+type ClusterSource struct {
+	// KubeconfigFiles are the kubeconfig files to load. When empty, the ones
+	// listed by the `KUBECONFIG` environment variable, or `~/.kube/config`,
+	// are used.
+	KubeconfigFiles []string
+	// Context is the kubeconfig context to use. When empty, the current
+	// context is used.
+	Context string
+	// OpenAPIv3 makes the source query the `/openapi/v3` endpoint, instead of
+	// the `/openapi/v2` one.
+	OpenAPIv3 bool
+	// Timeout of each request.
+	Timeout time.Duration
+}
+
+// NewClusterSource returns a source that uses the current context of the
+// default kubeconfig files.
+func NewClusterSource() *ClusterSource {
+	return &ClusterSource{
+		Timeout: DefaultDownloadTimeout,
+	}
+}
+
+// clusterVersion holds the fields of the response of the `/version` endpoint
+// that are relevant to the generator.
+type clusterVersion struct {
+	GitVersion string `json:"gitVersion"`
+}
+
+// openAPIv3Discovery is the response of the `/openapi/v3` endpoint, it lists
+// the per group-version documents.
+type openAPIv3Discovery struct {
+	Paths map[string]struct {
+		ServerRelativeURL string `json:"serverRelativeURL"`
+	} `json:"paths"`
+}
+
+// Fetch returns the OpenAPI document served by the cluster, converted to the
+// swagger format when `/openapi/v3` is used. The Kubernetes version is the
+// one reported by the API server.
+func (s *ClusterSource) Fetch() (*SwaggerData, error) {
+	config, err := loadKubeconfig(s.KubeconfigFiles, s.Context)
+	if err != nil {
+		return nil, err
+	}
+	connection, err := newClusterConnection(config)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := s.fetchVersion(connection)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Connected to cluster", "server", connection.server, "kubernetesVersion", version)
+
+	var data []byte
+	if s.OpenAPIv3 {
+		data, err = s.fetchOpenAPIv3(connection, version)
+	} else {
+		data, err = s.get(connection, clusterOpenAPIv2Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SwaggerData{
+		Data:              data,
+		KubernetesVersion: version,
+	}, nil
+}
+
+func (s *ClusterSource) fetchVersion(connection *clusterConnection) (string, error) {
+	data, err := s.get(connection, clusterVersionPath)
+	if err != nil {
+		return "", err
+	}
+
+	version := clusterVersion{}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return "", errors.Wrap(err, "cannot decode the version of the cluster")
+	}
+	if version.GitVersion == "" {
+		return "", errors.New("the cluster didn't report its version")
+	}
+
+	return strings.TrimPrefix(version.GitVersion, "v"), nil
+}
+
+// fetchOpenAPIv3 downloads all the per group-version documents and merges
+// them into a single swagger document.
+func (s *ClusterSource) fetchOpenAPIv3(connection *clusterConnection, version string) ([]byte, error) {
+	data, err := s.get(connection, clusterOpenAPIv3Path)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery := openAPIv3Discovery{}
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return nil, errors.Wrap(err, "cannot decode the list of OpenAPI v3 documents")
+	}
+
+	groupVersions := make([]string, 0, len(discovery.Paths))
+	for groupVersion := range discovery.Paths {
+		groupVersions = append(groupVersions, groupVersion)
+	}
+	slices.Sort(groupVersions)
+
+	swagger := newSwagger(version)
+	for _, groupVersion := range groupVersions {
+		documentPath := discovery.Paths[groupVersion].ServerRelativeURL
+		if documentPath == "" {
+			documentPath = clusterOpenAPIv3Path + "/" + groupVersion
+		}
+
+		data, err := s.get(connection, documentPath)
+		if err != nil {
+			return nil, err
+		}
+		document, err := decodeOpenAPIv3Document(groupVersion, data)
+		if err != nil {
+			return nil, err
+		}
+		if err := addOpenAPIv3Document(swagger, groupVersion, document); err != nil {
+			return nil, err
+		}
+	}
+	swagger.Info.Version = version
+
+	if len(swagger.Definitions) == 0 {
+		return nil, fmt.Errorf("no schema served by %s", connection.server)
+	}
+
+	data, err = swagger.MarshalJSON()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot encode swagger file")
+	}
+	return data, nil
+}
+
+// get performs a GET request against the API server, path can include a
+// query string.
+func (s *ClusterSource) get(connection *clusterConnection, path string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	requestURL := connection.server + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create request for %s", requestURL)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := connection.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot query %s", requestURL)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Info("failed to close response body", "error", cerr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read contents of response from %s", requestURL)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %s failed with status code: %d and body: %s", requestURL, resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
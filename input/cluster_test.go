@@ -0,0 +1,211 @@
+package input
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testClusterToken = "secret-token"
+
+// newTestCluster returns a stand-in of an API server serving the recorded
+// documents, together with the kubeconfig file pointing at it.
+func newTestCluster(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	serveFile := func(path, file string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			data, err := os.ReadFile(file)
+			if !assert.NoError(t, err) {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(data)
+		})
+	}
+	mux.HandleFunc("/version", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"major": "1", "minor": "33", "gitVersion": "v1.33.1"}`))
+	})
+	serveFile("/openapi/v2", filepath.Join("testdata", "cluster", "openapi-v2.json"))
+	mux.HandleFunc("/openapi/v3", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"paths": {
+			"apis/example.com/v1": {"serverRelativeURL": "/openapi/v3/apis/example.com/v1?hash=ABCD"},
+			"api/v1": {"serverRelativeURL": "/openapi/v3/api/v1?hash=EFGH"}
+		}}`))
+	})
+	serveFile("/openapi/v3/api/v1", filepath.Join("testdata", "openapi-v3", "api__v1_openapi.json"))
+	serveFile("/openapi/v3/apis/example.com/v1", filepath.Join("testdata", "openapi-v3", "apis__example.com__v1_openapi.json"))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testClusterToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfigFile := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfigFile, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: test
+  user:
+    token: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+- name: anonymous
+  context:
+    cluster: test
+`, server.URL, base64.StdEncoding.EncodeToString(caData), testClusterToken)), 0o600))
+
+	return server, kubeconfigFile
+}
+
+func TestClusterSourceOpenAPIv2(t *testing.T) {
+	_, kubeconfigFile := newTestCluster(t)
+
+	source := NewClusterSource()
+	source.KubeconfigFiles = []string{kubeconfigFile}
+
+	swaggerData, err := source.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, "1.33.1", swaggerData.KubernetesVersion)
+
+	expected, err := os.ReadFile(filepath.Join("testdata", "cluster", "openapi-v2.json"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, swaggerData.Data)
+}
+
+func TestClusterSourceOpenAPIv3(t *testing.T) {
+	_, kubeconfigFile := newTestCluster(t)
+
+	source := NewClusterSource()
+	source.KubeconfigFiles = []string{kubeconfigFile}
+	source.OpenAPIv3 = true
+
+	swaggerData, err := source.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, "1.33.1", swaggerData.KubernetesVersion)
+
+	swagger := openapi_spec.Swagger{}
+	require.NoError(t, json.Unmarshal(swaggerData.Data, &swagger))
+	assert.Equal(t, "1.33.1", swagger.Info.Version)
+	assert.Contains(t, swagger.Definitions, "io.k8s.api.example.v1.Widget")
+	assert.Contains(t, swagger.Definitions, "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta")
+}
+
+func TestClusterSourceContext(t *testing.T) {
+	_, kubeconfigFile := newTestCluster(t)
+
+	source := NewClusterSource()
+	source.KubeconfigFiles = []string{kubeconfigFile}
+	source.Context = "anonymous"
+
+	_, err := source.Fetch()
+	require.ErrorContains(t, err, "status code: 401")
+
+	source.Context = "missing"
+	_, err = source.Fetch()
+	require.ErrorContains(t, err, `context "missing" does not exist`)
+}
+
+func TestLoadKubeconfigMergesFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	require.NoError(t, os.WriteFile(first, []byte(`current-context: dev
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+`), 0o600))
+	second := filepath.Join(dir, "second")
+	require.NoError(t, os.WriteFile(second, []byte(`current-context: prod
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443/
+users:
+- name: dev
+  user:
+    tokenFile: token
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("dev-token\n"), 0o600))
+
+	config, err := loadKubeconfig([]string{first, filepath.Join(dir, "missing"), second}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example.com:6443/", config.Host)
+	assert.Equal(t, filepath.Join(dir, "token"), config.BearerTokenFile)
+
+	_, err = loadKubeconfig([]string{filepath.Join(dir, "missing")}, "")
+	require.ErrorContains(t, err, "cannot load kubeconfig")
+}
+
+func TestClusterSourceExecCredentialPlugin(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	server, _ := newTestCluster(t)
+
+	// the plugin stands in for the ones of the managed clusters, like
+	// `aws eks get-token`
+	dir := t.TempDir()
+	plugin := filepath.Join(dir, "get-token")
+	require.NoError(t, os.WriteFile(plugin, []byte(fmt.Sprintf(`#!/bin/sh
+echo '{"apiVersion": "client.authentication.k8s.io/v1", "kind": "ExecCredential", "status": {"token": "%s"}}'
+`, testClusterToken)), 0o700)) //nolint:gosec // the plugin must be executable
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfigFile := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfigFile, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: eks
+clusters:
+- name: eks
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: eks
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: %s
+      interactiveMode: Never
+contexts:
+- name: eks
+  context:
+    cluster: eks
+    user: eks
+`, server.URL, base64.StdEncoding.EncodeToString(caData), plugin)), 0o600))
+
+	source := NewClusterSource()
+	source.KubeconfigFiles = []string{kubeconfigFile}
+
+	swaggerData, err := source.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, "1.33.1", swaggerData.KubernetesVersion)
+}
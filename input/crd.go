@@ -0,0 +1,231 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	crdAPIVersion = "apiextensions.k8s.io/v1"
+	crdKind       = "CustomResourceDefinition"
+)
+
+// customResourceDefinition holds the fields of an `apiextensions.k8s.io/v1`
+// CustomResourceDefinition that are relevant to the generator.
+type customResourceDefinition struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Versions []struct {
+			Name   string `json:"name"`
+			Served bool   `json:"served"`
+			Schema *struct {
+				OpenAPIV3Schema *openapi_spec.Schema `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// SwaggerFromCRDs builds a swagger document out of the CustomResourceDefinition
+// manifests found at the given paths. Each path can be either a YAML/JSON file
+// or a directory, which is searched recursively.
+//
+// One definition is created for each served version of each CRD. The ids of
+// the definitions follow the convention used by the Kubernetes API server:
+// the group is reversed and followed by the version and the kind, e.g.
+// `io.cert-manager.acme.v1.Challenge`.
+//
+// The definitions reference `ObjectMeta`, which is not part of the returned
+// document: AddFallbackDefinitions must be invoked, unless the document is
+// merged with one that provides it.
+func SwaggerFromCRDs(paths ...string) (*openapi_spec.Swagger, error) {
+	files, err := findManifests(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	swagger := newSwagger(unknownKubernetesVersion)
+	for _, file := range files {
+		crds, err := readCRDs(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, crd := range crds {
+			if err := addCRDDefinitions(swagger.Definitions, crd); err != nil {
+				return nil, errors.Wrapf(err, "cannot process CRD %s defined inside of %s", crd.Metadata.Name, file)
+			}
+		}
+	}
+
+	if len(swagger.Definitions) == 0 {
+		return nil, fmt.Errorf("no CustomResourceDefinition found inside of %v", paths)
+	}
+
+	return swagger, nil
+}
+
+// findManifests returns the sorted list of files to be processed.
+func findManifests(paths []string) ([]string, error) {
+	files := []string{}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot access %s", path)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot walk directory %s", path)
+		}
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// readCRDs returns all the CRDs defined inside of the given file. YAML
+// files can hold multiple documents, documents that are not CRDs are skipped.
+func readCRDs(file string) ([]customResourceDefinition, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", file)
+	}
+
+	crds := []customResourceDefinition{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var document map[string]interface{}
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrapf(err, "cannot decode %s", file)
+		}
+		if document == nil {
+			continue
+		}
+
+		// go-openapi types can be decoded only from JSON
+		jsonData, err := json.Marshal(document)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot convert %s to JSON", file)
+		}
+
+		crd := customResourceDefinition{}
+		if err := json.Unmarshal(jsonData, &crd); err != nil {
+			return nil, errors.Wrapf(err, "cannot decode %s", file)
+		}
+
+		if crd.APIVersion != crdAPIVersion || crd.Kind != crdKind {
+			slog.Info("Skipping document that is not a CustomResourceDefinition",
+				"file", file, "apiVersion", crd.APIVersion, "kind", crd.Kind)
+			continue
+		}
+		crds = append(crds, crd)
+	}
+
+	return crds, nil
+}
+
+func addCRDDefinitions(definitions openapi_spec.Definitions, crd customResourceDefinition) error {
+	if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
+		return errors.New("group and kind must be set")
+	}
+
+	for _, version := range crd.Spec.Versions {
+		if !version.Served {
+			slog.Info("Skipping version that is not served", "crd", crd.Metadata.Name, "version", version.Name)
+			continue
+		}
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			return fmt.Errorf("version %s doesn't have an openAPIV3Schema", version.Name)
+		}
+
+		schema := *version.Schema.OpenAPIV3Schema
+		patchCRDRootSchema(&schema, crd.Spec.Group, version.Name, crd.Spec.Names.Kind)
+
+		hoister := newInlineSchemaHoister(GroupVersionIDPrefix(crd.Spec.Group, version.Name), definitions)
+		if _, err := hoister.AddDefinition(crd.Spec.Names.Kind, schema); err != nil {
+			return errors.Wrapf(err, "cannot process version %s", version.Name)
+		}
+	}
+
+	return nil
+}
+
+// GroupVersionIDPrefix returns the prefix of the ids of the definitions
+// belonging to the given group and version. The group is reversed, like the
+// Kubernetes API server does: `cert-manager.io`, `v1` -> `io.cert-manager.v1.`.
+func GroupVersionIDPrefix(group, version string) string {
+	chunks := strings.Split(group, ".")
+	slices.Reverse(chunks)
+
+	return strings.Join(append(chunks, version), ".") + "."
+}
+
+// patchCRDRootSchema makes the root schema of a CRD look like the one of
+// a built-in Kubernetes resource: it references the real `ObjectMeta` type,
+// it has the `apiVersion` and `kind` fields and it provides the
+// `x-kubernetes-group-version-kind` extension.
+func patchCRDRootSchema(schema *openapi_spec.Schema, group, version, kind string) {
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]openapi_spec.Schema)
+	}
+	schema.Type = openapi_spec.StringOrArray{typeObject}
+
+	for _, name := range []string{apiVersionPropertyName, kindPropertyName} {
+		if _, found := schema.Properties[name]; !found {
+			schema.Properties[name] = *openapi_spec.StringProperty()
+		}
+	}
+
+	metadata := schema.Properties[objectMetaPropertyName]
+	metadata.Ref = openapi_spec.MustCreateRef(definitionsRefPrefix + objectMetaID)
+	metadata.Type = nil
+	metadata.Properties = nil
+	schema.Properties[objectMetaPropertyName] = metadata
+
+	schema.AddExtension(kubernetesGroupVersionKindKey, []interface{}{
+		map[string]interface{}{
+			"group":   group,
+			"version": version,
+			"kind":    kind,
+		},
+	})
+}
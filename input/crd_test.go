@@ -0,0 +1,102 @@
+package input
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+func TestGroupVersionIDPrefix(t *testing.T) {
+	tests := []struct {
+		group    string
+		version  string
+		expected string
+	}{
+		{"cert-manager.io", "v1", "io.cert-manager.v1."},
+		{"acme.cert-manager.io", "v1", "io.cert-manager.acme.v1."},
+		{"networking.istio.io", "v1beta1", "io.istio.networking.v1beta1."},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, GroupVersionIDPrefix(tt.group, tt.version))
+	}
+}
+
+func TestSwaggerFromCRDs(t *testing.T) {
+	swagger, err := SwaggerFromCRDs(filepath.Join("testdata", "crds"))
+	require.NoError(t, err)
+
+	expectedIDs := []string{
+		"com.example.v1.Widget",
+		"com.example.v1.WidgetSpec",
+		"com.example.v1.WidgetSpecConfig",
+		"com.example.v1.WidgetSpecParts",
+		"com.example.v1.WidgetStatus",
+		"com.example.tools.v1beta1.Gadget",
+		"com.example.tools.v1beta1.GadgetSpec",
+	}
+	for _, id := range expectedIDs {
+		assert.Contains(t, swagger.Definitions, id)
+	}
+	assert.NotContains(t, swagger.Definitions, objectMetaID, "fallback definitions are added only after merging the inputs")
+	assert.NotContains(t, swagger.Definitions, "com.example.v1alpha1.Widget", "versions that are not served must be skipped")
+
+	widget := swagger.Definitions["com.example.v1.Widget"]
+	metadata, specProperty := widget.Properties["metadata"], widget.Properties["spec"]
+	assert.Equal(t, "#/definitions/"+objectMetaID, metadata.Ref.String())
+	assert.Equal(t, "#/definitions/com.example.v1.WidgetSpec", specProperty.Ref.String())
+	assert.Contains(t, widget.Extensions, kubernetesGroupVersionKindKey)
+
+	gadget := swagger.Definitions["com.example.tools.v1beta1.Gadget"]
+	assert.Contains(t, gadget.Properties, "apiVersion", "apiVersion must be added when missing")
+	assert.Contains(t, gadget.Properties, "kind", "kind must be added when missing")
+
+	spec := swagger.Definitions["com.example.v1.WidgetSpec"]
+	port := spec.Properties["port"]
+	assert.Equal(t, "#/definitions/"+intOrStringID, port.Ref.String())
+	assert.Empty(t, port.AnyOf)
+	assert.Equal(t, "#/definitions/com.example.v1.WidgetSpecParts", spec.Properties["parts"].Items.Schema.Ref.String())
+	assert.Equal(t, []string{"string"}, []string(spec.Properties["labels"].AdditionalProperties.Schema.Type))
+
+	parts := swagger.Definitions["com.example.v1.WidgetSpecParts"]
+	nullable, found := parts.Properties["weight"].Extensions.GetBool("x-nullable")
+	assert.True(t, found && nullable, "nullable must be converted to x-nullable")
+	assert.False(t, parts.Properties["weight"].Nullable)
+}
+
+func TestGenerateGroupResourcesFromCRDs(t *testing.T) {
+	swagger, err := SwaggerFromCRDs(filepath.Join("testdata", "crds", "widgets.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, AddFallbackDefinitions(swagger))
+
+	plan, err := split.NewRefactoringPlan(swagger, swaggerhelpers.DefaultPackageMapping(), nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, plan.Packages, "com/example/v1")
+	assert.Contains(t, plan.Packages, "apimachinery/pkg/apis/meta/v1")
+	assert.True(t, plan.Interfaces.IsInterface("", "com/example/v1", "WidgetSpecConfig"),
+		"free-form objects must be handled as interfaces")
+
+	_, err = plan.DependenciesGraph()
+	require.NoError(t, err, "all the dependencies must be satisfied")
+
+	project, err := split.NewProject("/testout", "", split.LayoutGOPATH)
+	require.NoError(t, err)
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, split.NewGroupResource(fs).Generate(project, plan))
+
+	exists, err := afero.Exists(fs, filepath.Join("/testout", "src", "com/example/v1", "widget_gvk.go"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = afero.Exists(fs, filepath.Join("/testout", "src", "com/example/v1", "group_info.go"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
@@ -0,0 +1,289 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSwaggerURLTemplate is the location of the swagger file inside of
+	// the Kubernetes GitHub repository.
+	DefaultSwaggerURLTemplate = "https://github.com/kubernetes/kubernetes/raw/{{ .Ref }}/api/openapi-spec/swagger.json"
+
+	DefaultDownloadRetries = 3
+	DefaultDownloadTimeout = 2 * time.Minute
+
+	defaultRetryDelay = time.Second
+)
+
+// SwaggerData holds the swagger file of Kubernetes, together with its version.
+type SwaggerData struct {
+	Data              []byte
+	KubernetesVersion string
+}
+
+// SwaggerURLData is the data available to the templates of the download URLs.
+type SwaggerURLData struct {
+	// Version of Kubernetes, e.g. `1.33.0` or `1.34.0-rc.1`. When a git ref
+	// is requested, this is the ref itself, e.g. `release-1.33` or `master`
+	Version string
+	// Ref is the git ref of the Kubernetes repository, e.g. `v1.33.0`,
+	// `v1.34.0-rc.1`, `release-1.33` or `master`
+	Ref   string
+	Major uint64
+	Minor uint64
+	Patch uint64
+}
+
+// releaseBranchRegexp matches the release branches of Kubernetes, e.g.
+// `release-1.33`.
+var releaseBranchRegexp = regexp.MustCompile(`^release-(\d+)\.(\d+)$`) //nolint:gochecknoglobals // compiled once
+
+// parseKubeVersion returns the data describing the requested Kubernetes
+// version. Versions that are not valid semantic versions are handled as git
+// refs of the Kubernetes repository. The boolean value is false for refs,
+// since they can point to different commits over time.
+func parseKubeVersion(kubeVersion string) (SwaggerURLData, bool) {
+	if version, err := semver.ParseTolerant(kubeVersion); err == nil {
+		return SwaggerURLData{
+			Version: version.String(),
+			Ref:     "v" + version.String(),
+			Major:   version.Major,
+			Minor:   version.Minor,
+			Patch:   version.Patch,
+		}, true
+	}
+
+	urlData := SwaggerURLData{
+		Version: kubeVersion,
+		Ref:     kubeVersion,
+	}
+	if matches := releaseBranchRegexp.FindStringSubmatch(kubeVersion); matches != nil {
+		urlData.Major, _ = strconv.ParseUint(matches[1], 10, 64)
+		urlData.Minor, _ = strconv.ParseUint(matches[2], 10, 64)
+	}
+
+	return urlData, false
+}
+
+// SwaggerDownloader fetches the swagger file of a Kubernetes version.
+type SwaggerDownloader struct {
+	// URLTemplates are the templates of the locations of the swagger file,
+	// they are tried in order until one of them succeeds. Both `http(s)://`
+	// and `file://` URLs are supported.
+	URLTemplates []string
+	// Retries is the number of times a failed download is retried, before
+	// moving to the next URL.
+	Retries int
+	// Timeout of each download attempt.
+	Timeout time.Duration
+	// Cache holds the swagger files that have already been downloaded.
+	Cache *SwaggerCache
+	// Offline prevents any download. Only the swagger files stored inside of
+	// the cache can be used.
+	Offline bool
+	// ReadOnly prevents the downloaded files from being added to the cache,
+	// the cached ones are still used.
+	ReadOnly bool
+	// Lock holds the expected digests of the swagger files. No verification
+	// is done when nil.
+	Lock *SwaggerLock
+
+	retryDelay time.Duration
+}
+
+// NewSwaggerDownloader returns a downloader that fetches the swagger files
+// from GitHub, using the given cache.
+func NewSwaggerDownloader(cache *SwaggerCache) *SwaggerDownloader {
+	return &SwaggerDownloader{
+		URLTemplates: []string{DefaultSwaggerURLTemplate},
+		Retries:      DefaultDownloadRetries,
+		Timeout:      DefaultDownloadTimeout,
+		Cache:        cache,
+		retryDelay:   defaultRetryDelay,
+	}
+}
+
+// Download returns the swagger file for the Kubernetes version specified by
+// the user. Both released versions, including pre-releases like
+// `1.34.0-rc.1`, and git refs of the Kubernetes repository, like
+// `release-1.33` or `master`, are supported.
+//
+// The swagger file of a released version is looked up inside of the cache
+// first, git refs are always downloaded again unless running offline.
+// Downloaded files are added to the cache, unless the downloader is
+// read-only. The contents of the file are verified against the lock, when one
+// is set.
+func (d *SwaggerDownloader) Download(kubeVersion string) (*SwaggerData, error) {
+	if strings.TrimSpace(kubeVersion) == "" {
+		return nil, errors.New("the Kubernetes version cannot be empty")
+	}
+	urlData, released := parseKubeVersion(kubeVersion)
+	versionString := urlData.Version
+
+	data, err := d.Cache.Get(versionString)
+	cached := err == nil
+	switch {
+	case cached && !released && !d.Offline:
+		slog.Info("Ignoring cached swagger file, the git ref can point to a different commit", "ref", versionString)
+		cached = false
+		if data, err = d.fetch(urlData); err != nil {
+			return nil, err
+		}
+	case cached:
+		slog.Info("Using cached swagger file for Kubernetes", "version", versionString, "cache", d.Cache.Root())
+	case !errors.Is(err, ErrNotCached):
+		return nil, err
+	case d.Offline:
+		return nil, fmt.Errorf("running in offline mode: %w", err)
+	default:
+		data, err = d.fetch(urlData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the cached files are verified too: they could have been downloaded
+	// before the lock was created
+	if d.Lock != nil {
+		if err := d.Lock.Verify(versionString, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if !cached && !d.ReadOnly {
+		digest, err := d.Cache.Put(versionString, data)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Cached swagger file for Kubernetes", "version", versionString, "sha256", digest)
+	}
+
+	return &SwaggerData{Data: data, KubernetesVersion: versionString}, nil
+}
+
+// fetch tries all the URLs, in order, until the file is downloaded.
+func (d *SwaggerDownloader) fetch(urlData SwaggerURLData) ([]byte, error) {
+	var errs []error
+
+	for _, urlTemplate := range d.URLTemplates {
+		downloadURL, err := renderURLTemplate(urlTemplate, urlData)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := d.fetchWithRetries(downloadURL)
+		if err == nil {
+			return data, nil
+		}
+		slog.Warn("Cannot fetch swagger file", "downloadURL", downloadURL, "error", err)
+		errs = append(errs, err)
+	}
+
+	return nil, fmt.Errorf("cannot fetch swagger file of Kubernetes %s from any location: %v", urlData.Version, errs)
+}
+
+func renderURLTemplate(urlTemplate string, urlData SwaggerURLData) (string, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(urlTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot parse URL template %s", urlTemplate)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, urlData); err != nil {
+		return "", errors.Wrapf(err, "cannot render URL template %s", urlTemplate)
+	}
+
+	return buf.String(), nil
+}
+
+func (d *SwaggerDownloader) fetchWithRetries(downloadURL string) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(attempt) * d.retryDelay
+			slog.Info("Retrying download", "downloadURL", downloadURL, "attempt", attempt, "delay", delay, "error", err)
+			time.Sleep(delay)
+		}
+
+		var data []byte
+		var retryable bool
+		data, retryable, err = d.fetchURL(downloadURL)
+		if err == nil {
+			return data, nil
+		}
+		if !retryable {
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// fetchURL downloads the file. The boolean value is true when the failure
+// is transient and the download can be retried.
+func (d *SwaggerDownloader) fetchURL(downloadURL string) ([]byte, bool, error) {
+	parsedURL, err := url.Parse(downloadURL)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "invalid URL %s", downloadURL)
+	}
+
+	switch parsedURL.Scheme {
+	case "file":
+		slog.Info("Reading swagger file", "path", parsedURL.Path)
+		data, err := os.ReadFile(parsedURL.Path)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "cannot read swagger file %s", parsedURL.Path)
+		}
+		return data, false, nil
+	case "http", "https":
+	default:
+		return nil, false, fmt.Errorf("unsupported URL scheme '%s' of %s", parsedURL.Scheme, downloadURL)
+	}
+
+	slog.Info("Downloading swagger file", "downloadURL", downloadURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "cannot create request for %s", downloadURL)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "Cannot fetch swagger file from %s", downloadURL)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Info("failed to close response body", "error", cerr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "Cannot read contents of response from %s", downloadURL)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		retryable := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retryable, fmt.Errorf("response failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	}
+
+	return body, false, nil
+}
@@ -0,0 +1,213 @@
+package input
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSwagger = `{"swagger": "2.0"}`
+
+func newTestDownloader(t *testing.T, urlTemplates ...string) *SwaggerDownloader {
+	t.Helper()
+
+	cache, err := NewSwaggerCache(t.TempDir())
+	require.NoError(t, err)
+
+	downloader := NewSwaggerDownloader(cache)
+	downloader.URLTemplates = urlTemplates
+	downloader.retryDelay = 0
+
+	return downloader
+}
+
+func TestRenderURLTemplate(t *testing.T) {
+	urlData := SwaggerURLData{Version: "1.33.2", Ref: "v1.33.2", Major: 1, Minor: 33, Patch: 2}
+
+	rendered, err := renderURLTemplate(DefaultSwaggerURLTemplate, urlData)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/kubernetes/kubernetes/raw/v1.33.2/api/openapi-spec/swagger.json", rendered)
+
+	rendered, err = renderURLTemplate("https://mirror.example.com/k8s/{{ .Major }}.{{ .Minor }}/swagger.json", urlData)
+	require.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com/k8s/1.33/swagger.json", rendered)
+
+	_, err = renderURLTemplate("https://mirror.example.com/{{ .Unknown }}", urlData)
+	require.Error(t, err)
+}
+
+func TestParseKubeVersion(t *testing.T) {
+	cases := []struct {
+		kubeVersion string
+		expected    SwaggerURLData
+		released    bool
+	}{
+		{"1.33", SwaggerURLData{Version: "1.33.0", Ref: "v1.33.0", Major: 1, Minor: 33}, true},
+		{"v1.32.4", SwaggerURLData{Version: "1.32.4", Ref: "v1.32.4", Major: 1, Minor: 32, Patch: 4}, true},
+		{"1.34.0-rc.1", SwaggerURLData{Version: "1.34.0-rc.1", Ref: "v1.34.0-rc.1", Major: 1, Minor: 34}, true},
+		{"release-1.33", SwaggerURLData{Version: "release-1.33", Ref: "release-1.33", Major: 1, Minor: 33}, false},
+		{"master", SwaggerURLData{Version: "master", Ref: "master"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.kubeVersion, func(t *testing.T) {
+			urlData, released := parseKubeVersion(tc.kubeVersion)
+			assert.Equal(t, tc.expected, urlData)
+			assert.Equal(t, tc.released, released)
+		})
+	}
+}
+
+func TestDownloadGitRef(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/feature/new-api/swagger.json", r.URL.Path)
+		requests.Add(1)
+		_, _ = w.Write([]byte(testSwagger))
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t, server.URL+"/{{ .Ref }}/swagger.json")
+
+	swaggerData, err := downloader.Download("feature/new-api")
+	require.NoError(t, err)
+	assert.Equal(t, "feature/new-api", swaggerData.KubernetesVersion)
+
+	// git refs can move, they are downloaded again
+	_, err = downloader.Download("feature/new-api")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+
+	downloader.Offline = true
+	swaggerData, err = downloader.Download("feature/new-api")
+	require.NoError(t, err)
+	assert.Equal(t, testSwagger, string(swaggerData.Data))
+	assert.Equal(t, int32(2), requests.Load())
+
+	entries, err := downloader.Cache.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "feature/new-api", entries[0].KubernetesVersion)
+}
+
+func TestDownloadRetriesAndCaches(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1.33.0/swagger.json", r.URL.Path)
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(testSwagger))
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t, server.URL+"/v{{ .Version }}/swagger.json")
+
+	swaggerData, err := downloader.Download("1.33")
+	require.NoError(t, err)
+	assert.Equal(t, testSwagger, string(swaggerData.Data))
+	assert.Equal(t, "1.33.0", swaggerData.KubernetesVersion)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// the second download is served by the cache
+	downloader.Offline = true
+	swaggerData, err = downloader.Download("1.33.0")
+	require.NoError(t, err)
+	assert.Equal(t, testSwagger, string(swaggerData.Data))
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestDownloadFallsBackToMirrors(t *testing.T) {
+	var requests atomic.Int32
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+
+	swaggerFile := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(swaggerFile, []byte(testSwagger), 0o600))
+
+	downloader := newTestDownloader(t, notFound.URL+"/swagger.json", "file://"+swaggerFile)
+
+	swaggerData, err := downloader.Download("1.33")
+	require.NoError(t, err)
+	assert.Equal(t, testSwagger, string(swaggerData.Data))
+	assert.Equal(t, int32(1), requests.Load(), "client errors must not be retried")
+}
+
+func TestDownloadOffline(t *testing.T) {
+	downloader := newTestDownloader(t, "file:///does/not/exist")
+	downloader.Offline = true
+
+	_, err := downloader.Download("1.33")
+	require.ErrorIs(t, err, ErrNotCached)
+}
+
+func TestDownloadVerifiesLock(t *testing.T) {
+	swaggerFile := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(swaggerFile, []byte(testSwagger), 0o600))
+
+	lockFile := filepath.Join(t.TempDir(), "swagger.lock")
+	lock, err := LoadSwaggerLock(lockFile)
+	require.NoError(t, err)
+	lock.Set("1.33.0", Digest([]byte(testSwagger)))
+	lock.Set("1.32.0", Digest([]byte("something else")))
+	require.NoError(t, lock.Save())
+
+	lock, err = LoadSwaggerLock(lockFile)
+	require.NoError(t, err)
+
+	downloader := newTestDownloader(t, "file://"+swaggerFile)
+	downloader.Lock = lock
+
+	_, err = downloader.Download("1.33")
+	require.NoError(t, err)
+
+	_, err = downloader.Download("1.32")
+	require.ErrorContains(t, err, "checksum mismatch")
+	_, err = downloader.Cache.Get("1.32.0")
+	require.ErrorIs(t, err, ErrNotCached, "files that fail verification must not be cached")
+
+	_, err = downloader.Download("1.31")
+	require.ErrorContains(t, err, "is not pinned")
+}
+
+func TestLoadSwaggerLockErrors(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "swagger.lock")
+	require.NoError(t, os.WriteFile(lockFile, []byte("1.33.0\n"), 0o600))
+
+	_, err := LoadSwaggerLock(lockFile)
+	require.ErrorContains(t, err, "swagger.lock:1")
+}
+
+func TestDownloadReadOnly(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(testSwagger))
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t, server.URL+"/v{{ .Version }}/swagger.json")
+	downloader.ReadOnly = true
+
+	swaggerData, err := downloader.Download("1.33")
+	require.NoError(t, err)
+	assert.Equal(t, testSwagger, string(swaggerData.Data))
+	entries, err := downloader.Cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// the file is downloaded again, it has not been cached
+	_, err = downloader.Download("1.33")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	// register the auth-provider plugins supported by kubectl
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// clusterConnection holds everything needed to talk with the API server of
+// a cluster.
+type clusterConnection struct {
+	server string
+	client *http.Client
+}
+
+// loadKubeconfig reads the kubeconfig files and returns the configuration
+// of the given context, or of the current context when empty.
+//
+// The files are loaded by client-go, like kubectl does: multiple files are
+// merged, the `KUBECONFIG` environment variable and `~/.kube/config` are
+// used when no file is given.
+func loadKubeconfig(files []string, contextName string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	switch len(files) {
+	case 0:
+	case 1:
+		// unlike the merged ones, an explicit file must exist
+		rules.ExplicitPath = files[0]
+	default:
+		rules.Precedence = files
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load kubeconfig")
+	}
+	return config, nil
+}
+
+// newClusterConnection returns the connection to the cluster of the
+// configuration. The client authenticates the requests with the credentials
+// of the user, including the ones returned by exec and auth-provider
+// plugins.
+func newClusterConnection(config *rest.Config) (*clusterConnection, error) {
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create the client of %s", config.Host)
+	}
+
+	return &clusterConnection{
+		server: strings.TrimSuffix(config.Host, "/"),
+		client: client,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const swaggerLockHeader = `# Expected SHA-256 digests of the swagger files of Kubernetes.
+# This file is maintained by k8s-objects-generator.
+`
+
+// SwaggerLock holds the expected SHA-256 digests of the swagger files, by
+// Kubernetes version. The lock file has one entry per line:
+//
+//	<kubernetes version> <hex encoded sha256 digest>
+//
+// Empty lines and lines starting with `#` are ignored.
+type SwaggerLock struct {
+	path    string
+	digests map[string]string
+}
+
+// LoadSwaggerLock reads the lock file. An empty lock is returned when the file
+// doesn't exist.
+func LoadSwaggerLock(path string) (*SwaggerLock, error) {
+	lock := &SwaggerLock{
+		path:    path,
+		digests: make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return lock, nil
+		}
+		return nil, errors.Wrapf(err, "cannot read lock file %s", path)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 { //nolint:mnd // version and digest
+			return nil, fmt.Errorf("%s:%d: expected '<version> <sha256>', got '%s'", path, lineNumber, line)
+		}
+		if _, known := lock.digests[fields[0]]; known {
+			return nil, fmt.Errorf("%s:%d: version %s is listed more than once", path, lineNumber, fields[0])
+		}
+		lock.digests[fields[0]] = strings.ToLower(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "cannot read lock file %s", path)
+	}
+
+	return lock, nil
+}
+
+// Verify returns an error when the digest of the data doesn't match the one
+// recorded for the Kubernetes version, or when the version is not part of the
+// lock.
+func (l *SwaggerLock) Verify(kubernetesVersion string, data []byte) error {
+	expected, known := l.digests[kubernetesVersion]
+	if !known {
+		return fmt.Errorf("Kubernetes %s is not pinned inside of the lock file %s", kubernetesVersion, l.path)
+	}
+
+	if actual := Digest(data); actual != expected {
+		return fmt.Errorf("checksum mismatch for the swagger file of Kubernetes %s: expected %s, got %s",
+			kubernetesVersion, expected, actual)
+	}
+
+	return nil
+}
+
+// Set records the digest of the swagger file of the Kubernetes version.
+func (l *SwaggerLock) Set(kubernetesVersion, digest string) {
+	l.digests[kubernetesVersion] = digest
+}
+
+// Save writes the lock file, the entries are sorted by version.
+func (l *SwaggerLock) Save() error {
+	versions := make([]string, 0, len(l.digests))
+	for version := range l.digests {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	var buf bytes.Buffer
+	buf.WriteString(swaggerLockHeader)
+	for _, version := range versions {
+		fmt.Fprintf(&buf, "%s %s\n", version, l.digests[version])
+	}
+
+	if err := os.WriteFile(l.path, buf.Bytes(), 0o600); err != nil {
+		return errors.Wrapf(err, "cannot write lock file %s", l.path)
+	}
+
+	return nil
+}
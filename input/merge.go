@@ -0,0 +1,170 @@
+package input
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+// FallbackDefinitionsSource is the source of the definitions added by
+// AddFallbackDefinitions.
+const FallbackDefinitionsSource = "built-in ObjectMeta definitions"
+
+// SwaggerInput is a swagger document, together with the description of where
+// it comes from.
+type SwaggerInput struct {
+	// Source describes the origin of the document, e.g. the path of the file
+	// or the requested Kubernetes version
+	Source            string
+	Swagger           *openapi_spec.Swagger
+	KubernetesVersion string
+
+	// data is the original encoding of the document, when known
+	data []byte
+}
+
+// NewSwaggerInput decodes the swagger document.
+func NewSwaggerInput(source string, swaggerData *SwaggerData) (SwaggerInput, error) {
+	swagger := openapi_spec.Swagger{}
+	if err := swagger.UnmarshalJSON(swaggerData.Data); err != nil {
+		return SwaggerInput{}, errors.Wrapf(err, "cannot decode swagger file of %s", source)
+	}
+
+	return SwaggerInput{
+		Source:            source,
+		Swagger:           &swagger,
+		KubernetesVersion: swaggerData.KubernetesVersion,
+		data:              swaggerData.Data,
+	}, nil
+}
+
+// Digest returns the hex encoded SHA-256 digest of the original encoding of
+// the document. The documents converted to swagger, like the CRDs, don't have
+// one: the digest of their swagger encoding is returned instead.
+func (i *SwaggerInput) Digest() (string, error) {
+	data := i.data
+	if data == nil {
+		var err error
+		if data, err = i.Swagger.MarshalJSON(); err != nil {
+			return "", errors.Wrapf(err, "cannot encode swagger file of %s", i.Source)
+		}
+	}
+	return Digest(data), nil
+}
+
+// InputDigest is the digest of an input, see SwaggerInput.Digest.
+type InputDigest struct {
+	Source string
+	SHA256 string
+}
+
+// MergedSwagger is the result of merging multiple inputs.
+type MergedSwagger struct {
+	Swagger *openapi_spec.Swagger
+	// KubernetesVersion is the version of Kubernetes reported by the inputs,
+	// the versions are comma separated when the inputs do not agree
+	KubernetesVersion string
+	// Sources maps the id of each definition to the sources defining it
+	Sources map[string][]string
+	// Inputs are the digests of the merged inputs, in order
+	Inputs []InputDigest
+
+	// data is the original encoding of the document, set when there's
+	// nothing to merge
+	data []byte
+}
+
+// MergeSwaggers merges the definitions of all the inputs into a single
+// swagger document. The same definition can be provided by multiple inputs
+// (e.g. an OpenAPI document fetched from a cluster and the upstream swagger
+// file), as long as all of them define it in the same way.
+//
+// AddFallbackDefinitions is invoked on the merged document, hence the types
+// defined by CRDs can reference the ones of any other input.
+func MergeSwaggers(inputs []SwaggerInput) (*MergedSwagger, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("no input to merge")
+	}
+
+	swagger := newSwagger(unknownKubernetesVersion)
+	if inputs[0].Swagger.Info != nil {
+		swagger.Info.Version = inputs[0].Swagger.Info.Version
+	}
+	if inputs[0].Swagger.Swagger != "" {
+		swagger.Swagger = inputs[0].Swagger.Swagger
+	}
+
+	sources := make(map[string][]string)
+	versions := []string{}
+	digests := make([]InputDigest, 0, len(inputs))
+	for _, input := range inputs {
+		digest, err := input.Digest()
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, InputDigest{Source: input.Source, SHA256: digest})
+
+		if input.KubernetesVersion != "" && input.KubernetesVersion != unknownKubernetesVersion &&
+			!slices.Contains(versions, input.KubernetesVersion) {
+			versions = append(versions, input.KubernetesVersion)
+		}
+
+		for id, definition := range input.Swagger.Definitions {
+			if known, found := swagger.Definitions[id]; found && !reflect.DeepEqual(known, definition) {
+				return nil, fmt.Errorf("definition %s is defined differently by %s and %s",
+					id, strings.Join(sources[id], ", "), input.Source)
+			}
+			swagger.Definitions[id] = definition
+			sources[id] = append(sources[id], input.Source)
+		}
+	}
+
+	if err := AddFallbackDefinitions(swagger); err != nil {
+		return nil, err
+	}
+	for id := range swagger.Definitions {
+		if _, found := sources[id]; !found {
+			sources[id] = []string{FallbackDefinitionsSource}
+		}
+	}
+
+	kubernetesVersion := unknownKubernetesVersion
+	if len(versions) > 0 {
+		kubernetesVersion = strings.Join(versions, ",")
+	}
+
+	merged := &MergedSwagger{
+		Swagger:           swagger,
+		KubernetesVersion: kubernetesVersion,
+		Sources:           sources,
+		Inputs:            digests,
+	}
+	// keep a single input untouched, unless fallback definitions were added
+	if len(inputs) == 1 && len(swagger.Definitions) == len(inputs[0].Swagger.Definitions) {
+		merged.data = inputs[0].data
+	}
+
+	return merged, nil
+}
+
+// SwaggerData encodes the merged swagger document. When a single document
+// has been provided, and nothing has been added to it, its original encoding
+// is returned.
+func (m *MergedSwagger) SwaggerData() (*SwaggerData, error) {
+	data := m.data
+	if data == nil {
+		var err error
+		if data, err = m.Swagger.MarshalJSON(); err != nil {
+			return nil, errors.Wrap(err, "cannot encode swagger file")
+		}
+	}
+
+	return &SwaggerData{
+		Data:              data,
+		KubernetesVersion: m.KubernetesVersion,
+	}, nil
+}
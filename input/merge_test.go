@@ -0,0 +1,126 @@
+package input
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+const (
+	testCoreSwagger = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "unversioned"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.api.core.v1.PodSpec": {
+      "type": "object",
+      "properties": {"nodeName": {"type": "string"}}
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+      "type": "object",
+      "properties": {"name": {"type": "string"}}
+    }
+  }
+}`
+	testAggregatedSwagger = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.33.1"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.api.core.v1.PodSpec": {
+      "type": "object",
+      "properties": {"nodeName": {"type": "string"}}
+    },
+    "com.example.batch.v1.Job": {
+      "type": "object",
+      "properties": {
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+        "template": {"$ref": "#/definitions/io.k8s.api.core.v1.PodSpec"}
+      }
+    }
+  }
+}`
+)
+
+func newTestSwaggerInput(t *testing.T, source, data, kubernetesVersion string) SwaggerInput {
+	t.Helper()
+
+	input, err := NewSwaggerInput(source, &SwaggerData{Data: []byte(data), KubernetesVersion: kubernetesVersion})
+	require.NoError(t, err)
+
+	return input
+}
+
+func TestMergeSwaggers(t *testing.T) {
+	crds, err := SwaggerFromCRDs(filepath.Join("testdata", "crds", "widgets.yaml"))
+	require.NoError(t, err)
+
+	merged, err := MergeSwaggers([]SwaggerInput{
+		newTestSwaggerInput(t, "core.json", testCoreSwagger, "1.33.1"),
+		newTestSwaggerInput(t, "aggregated.json", testAggregatedSwagger, "1.33.1"),
+		{Source: "widgets.yaml", Swagger: crds},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.33.1", merged.KubernetesVersion)
+	assert.Equal(t, []string{"core.json", "aggregated.json"}, merged.Sources["io.k8s.api.core.v1.PodSpec"])
+	assert.Equal(t, []string{"aggregated.json"}, merged.Sources["com.example.batch.v1.Job"])
+	assert.Equal(t, []string{"widgets.yaml"}, merged.Sources["com.example.v1.Widget"])
+	assert.Equal(t, []string{"core.json"}, merged.Sources[objectMetaID], "ObjectMeta of the inputs must win over the fallback one")
+	assert.Equal(t, []string{FallbackDefinitionsSource}, merged.Sources[intOrStringID], "missing definitions must be added")
+
+	require.Len(t, merged.Inputs, 3)
+	assert.Equal(t, InputDigest{Source: "core.json", SHA256: Digest([]byte(testCoreSwagger))}, merged.Inputs[0])
+	assert.Equal(t, InputDigest{Source: "aggregated.json", SHA256: Digest([]byte(testAggregatedSwagger))}, merged.Inputs[1])
+	// the CRDs are digested once converted to swagger
+	crdsData, err := crds.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, InputDigest{Source: "widgets.yaml", SHA256: Digest(crdsData)}, merged.Inputs[2])
+
+	plan, err := split.NewRefactoringPlan(merged.Swagger, swaggerhelpers.DefaultPackageMapping(), nil)
+	require.NoError(t, err)
+	_, err = plan.DependenciesGraph()
+	require.NoError(t, err, "references across inputs must be resolved")
+
+	plan.AssignSources(merged.Sources)
+	assert.Equal(t, []string{"aggregated.json", "core.json"}, plan.Sources["api/core/v1"])
+	assert.Equal(t, []string{"widgets.yaml"}, plan.Sources["com/example/v1"])
+	assert.Equal(t, []string{FallbackDefinitionsSource}, plan.Sources["apimachinery/pkg/util/intstr"])
+}
+
+func TestMergeSwaggersConflicts(t *testing.T) {
+	conflicting := `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "unversioned"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.api.core.v1.PodSpec": {
+      "type": "object",
+      "properties": {"hostname": {"type": "string"}}
+    }
+  }
+}`
+
+	_, err := MergeSwaggers([]SwaggerInput{
+		newTestSwaggerInput(t, "core.json", testCoreSwagger, unknownKubernetesVersion),
+		newTestSwaggerInput(t, "other.json", conflicting, unknownKubernetesVersion),
+	})
+	require.ErrorContains(t, err, "definition io.k8s.api.core.v1.PodSpec is defined differently by core.json and other.json")
+}
+
+func TestMergeSwaggersSingleInput(t *testing.T) {
+	merged, err := MergeSwaggers([]SwaggerInput{
+		newTestSwaggerInput(t, "core.json", testCoreSwagger, "1.33.0"),
+	})
+	require.NoError(t, err)
+
+	swaggerData, err := merged.SwaggerData()
+	require.NoError(t, err)
+	assert.Equal(t, testCoreSwagger, string(swaggerData.Data), "a single input must not be re-encoded")
+	assert.Equal(t, "1.33.0", swaggerData.KubernetesVersion)
+}
@@ -0,0 +1,185 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+const componentsSchemasRefPrefix = "#/components/schemas/"
+
+// openAPIv3Document holds the fields of an OpenAPI v3 document that are
+// relevant to the generator.
+type openAPIv3Document struct {
+	OpenAPI    string             `json:"openapi"`
+	Info       *openapi_spec.Info `json:"info"`
+	Components struct {
+		Schemas map[string]openapi_spec.Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// SwaggerFromOpenAPIv3 builds a swagger document out of the OpenAPI v3
+// documents published by Kubernetes, one per group-version, under
+// `api/openapi-spec/v3`. The path can be either a single document or a
+// directory holding them.
+//
+// The schemas defined under `components/schemas` are converted into OpenAPI v2
+// definitions, so that they can be processed like the ones of the
+// `swagger.json` file.
+func SwaggerFromOpenAPIv3(path string) (*openapi_spec.Swagger, error) {
+	files, err := findOpenAPIv3Documents(path)
+	if err != nil {
+		return nil, err
+	}
+
+	swagger := newSwagger(unknownKubernetesVersion)
+	for _, file := range files {
+		document, err := readOpenAPIv3Document(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := addOpenAPIv3Document(swagger, file, document); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(swagger.Definitions) == 0 {
+		return nil, fmt.Errorf("no schema found inside of %s", path)
+	}
+
+	return swagger, nil
+}
+
+func findOpenAPIv3Documents(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot access %s", path)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list files inside of %s", path)
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+func readOpenAPIv3Document(file string) (openAPIv3Document, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return openAPIv3Document{}, errors.Wrapf(err, "cannot read %s", file)
+	}
+
+	return decodeOpenAPIv3Document(file, data)
+}
+
+// decodeOpenAPIv3Document decodes the document, source is used only to
+// report errors.
+func decodeOpenAPIv3Document(source string, data []byte) (openAPIv3Document, error) {
+	document := openAPIv3Document{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return openAPIv3Document{}, errors.Wrapf(err, "cannot decode %s", source)
+	}
+	if !strings.HasPrefix(document.OpenAPI, "3.") {
+		return openAPIv3Document{}, fmt.Errorf("%s is not an OpenAPI v3 document, openapi version: '%s'", source, document.OpenAPI)
+	}
+
+	slog.Info("Loaded OpenAPI v3 document", "source", source, "schemas", len(document.Components.Schemas))
+	return document, nil
+}
+
+// addOpenAPIv3Document adds the schemas of the document to the swagger one.
+func addOpenAPIv3Document(swagger *openapi_spec.Swagger, source string, document openAPIv3Document) error {
+	if document.Info != nil && document.Info.Version != "" {
+		swagger.Info.Version = document.Info.Version
+	}
+
+	if err := addOpenAPIv3Schemas(swagger.Definitions, document.Components.Schemas); err != nil {
+		return errors.Wrapf(err, "cannot process %s", source)
+	}
+
+	return nil
+}
+
+// addOpenAPIv3Schemas converts the schemas and adds them to the definitions.
+// The same schema is usually defined by many documents (e.g. `ObjectMeta`),
+// all of them must be equal.
+func addOpenAPIv3Schemas(definitions openapi_spec.Definitions, schemas map[string]openapi_spec.Schema) error {
+	for id, schema := range schemas {
+		normalizeOpenAPIv3Schema(&schema)
+
+		if known, found := definitions[id]; found {
+			if !reflect.DeepEqual(known, schema) {
+				return fmt.Errorf("schema %s is defined differently by another document", id)
+			}
+			continue
+		}
+		definitions[id] = schema
+	}
+
+	return nil
+}
+
+// normalizeOpenAPIv3Schema recursively rewrites the schema to make it look like
+// the OpenAPI v2 definitions of Kubernetes.
+func normalizeOpenAPIv3Schema(schema *openapi_spec.Schema) {
+	// OpenAPI v3 doesn't allow siblings of `$ref`, hence Kubernetes wraps
+	// references into `allOf` to attach a description and a default value
+	// to them
+	if len(schema.AllOf) == 1 && schema.AllOf[0].Ref.String() != "" {
+		schema.Ref = schema.AllOf[0].Ref
+		schema.AllOf = nil
+		schema.Default = nil
+	}
+
+	if ref := schema.Ref.String(); strings.HasPrefix(ref, componentsSchemasRefPrefix) {
+		schema.Ref = openapi_spec.MustCreateRef(definitionsRefPrefix + strings.TrimPrefix(ref, componentsSchemasRefPrefix))
+	}
+
+	if isZeroDefault(schema) {
+		schema.Default = nil
+	}
+
+	normalizeSchema(schema)
+
+	for name, property := range schema.Properties {
+		normalizeOpenAPIv3Schema(&property)
+		schema.Properties[name] = property
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		normalizeOpenAPIv3Schema(schema.Items.Schema)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		normalizeOpenAPIv3Schema(schema.AdditionalProperties.Schema)
+	}
+	for i := range schema.AllOf {
+		normalizeOpenAPIv3Schema(&schema.AllOf[i])
+	}
+}
+
+// isZeroDefault returns true when the default value of the schema is the zero
+// value of its type. Kubernetes sets these defaults only to document the
+// behaviour of the API server, they carry no information for the generated code.
+func isZeroDefault(schema *openapi_spec.Schema) bool {
+	if schema.Default == nil {
+		return false
+	}
+
+	value := reflect.ValueOf(schema.Default)
+	if value.Kind() == reflect.Map || value.Kind() == reflect.Slice {
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
@@ -0,0 +1,72 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+func TestSwaggerFromOpenAPIv3(t *testing.T) {
+	swagger, err := SwaggerFromOpenAPIv3(filepath.Join("testdata", "openapi-v3"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2.0", swagger.Swagger)
+	assert.Equal(t, "unversioned", swagger.Info.Version)
+	assert.Len(t, swagger.Definitions, 5)
+
+	widget := swagger.Definitions["io.k8s.api.example.v1.Widget"]
+
+	metadata := widget.Properties["metadata"]
+	assert.Equal(t, "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta", metadata.Ref.String())
+	assert.Empty(t, metadata.AllOf)
+	assert.Nil(t, metadata.Default)
+	assert.Equal(t, "Standard object's metadata.", metadata.Description)
+
+	tags := widget.Properties["tags"]
+	assert.Equal(t, "#/definitions/io.k8s.api.example.v1.Tag", tags.Items.Schema.Ref.String())
+
+	assert.Equal(t, "TCP", widget.Properties["protocol"].Default, "non zero defaults must be kept")
+	assert.Nil(t, widget.Properties["name"].Default, "zero defaults must be removed")
+
+	nullable, found := widget.Properties["weight"].Extensions.GetBool("x-nullable")
+	assert.True(t, found && nullable)
+
+	intOrString := swagger.Definitions["io.k8s.apimachinery.pkg.util.intstr.IntOrString"]
+	assert.Equal(t, openapi_spec.StringOrArray{"string"}, intOrString.Type)
+	assert.Empty(t, intOrString.OneOf)
+
+	plan, err := split.NewRefactoringPlan(swagger, swaggerhelpers.DefaultPackageMapping(), nil)
+	require.NoError(t, err)
+	for _, pkg := range []string{"api/example/v1", "api/core/v1", "apimachinery/pkg/apis/meta/v1", "apimachinery/pkg/util/intstr"} {
+		assert.Contains(t, plan.Packages, pkg)
+	}
+	_, err = plan.DependenciesGraph()
+	require.NoError(t, err)
+}
+
+func TestSwaggerFromOpenAPIv3Conflicts(t *testing.T) {
+	dir := t.TempDir()
+
+	documents := map[string]string{
+		"a.json": `{"openapi": "3.0.0", "components": {"schemas": {"io.k8s.api.core.v1.Pod": {"type": "object"}}}}`,
+		"b.json": `{"openapi": "3.0.0", "components": {"schemas": {"io.k8s.api.core.v1.Pod": {"type": "string"}}}}`,
+	}
+	for name, content := range documents {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	_, err := SwaggerFromOpenAPIv3(dir)
+	require.ErrorContains(t, err, "io.k8s.api.core.v1.Pod is defined differently")
+}
+
+func TestSwaggerFromOpenAPIv3RejectsV2(t *testing.T) {
+	_, err := SwaggerFromOpenAPIv3(filepath.Join("..", "split", "testdata", "test-swagger.json"))
+	require.ErrorContains(t, err, "is not an OpenAPI v3 document")
+}
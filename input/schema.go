@@ -0,0 +1,321 @@
+package input
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/iancoleman/strcase"
+	"github.com/pkg/errors"
+)
+
+const (
+	objectMetaID  = "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+	intOrStringID = "io.k8s.apimachinery.pkg.util.intstr.IntOrString"
+
+	kubernetesIntOrStringKey        = "x-kubernetes-int-or-string"
+	kubernetesPreserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+	kubernetesEmbeddedResourceKey   = "x-kubernetes-embedded-resource"
+	kubernetesGroupVersionKindKey   = "x-kubernetes-group-version-kind"
+
+	definitionsRefPrefix     = "#/definitions/"
+	unknownKubernetesVersion = "unknown"
+	swaggerVersion           = "2.0"
+	swaggerTitle             = "Kubernetes"
+
+	typeObject             = "object"
+	typeArray              = "array"
+	typeString             = "string"
+	objectMetaPropertyName = "metadata"
+	apiVersionPropertyName = "apiVersion"
+	kindPropertyName       = "kind"
+
+	// suffix appended to the name of a hoisted definition on its first
+	// collision with an already existing one
+	firstCollisionSuffix = 2
+)
+
+//nolint:gochecknoglobals // this is a constant list
+var primitiveTypes = []string{typeString, "integer", "number", "boolean"}
+
+// objectMetaDefinitions holds the upstream definitions of `ObjectMeta`, all
+// the types it references and `IntOrString`. These are needed by the types
+// generated from sources that do not ship them (e.g. CRDs).
+//
+//go:embed objectmeta_definitions.json
+var objectMetaDefinitions []byte
+
+// AddFallbackDefinitions adds the `ObjectMeta` and `IntOrString` definitions,
+// and the ones they reference, to the swagger document. Only the definitions
+// that are referenced but not provided by the document are added, hence this
+// must be invoked once all the inputs have been merged.
+func AddFallbackDefinitions(swagger *openapi_spec.Swagger) error {
+	fallback := openapi_spec.Swagger{}
+	if err := json.Unmarshal(objectMetaDefinitions, &fallback); err != nil {
+		return errors.Wrap(err, "cannot decode embedded ObjectMeta definitions")
+	}
+
+	if swagger.Definitions == nil {
+		swagger.Definitions = make(openapi_spec.Definitions)
+	}
+
+	missing := []string{}
+	for _, definition := range swagger.Definitions {
+		collectRefs(&definition, func(id string) {
+			missing = append(missing, id)
+		})
+	}
+
+	for len(missing) > 0 {
+		id := missing[0]
+		missing = missing[1:]
+		if _, known := swagger.Definitions[id]; known {
+			continue
+		}
+		definition, found := fallback.Definitions[id]
+		if !found {
+			continue
+		}
+		swagger.Definitions[id] = definition
+		collectRefs(&definition, func(id string) {
+			missing = append(missing, id)
+		})
+	}
+
+	return nil
+}
+
+// collectRefs invokes the callback with the id of each definition referenced
+// by the schema.
+func collectRefs(schema *openapi_spec.Schema, callback func(id string)) {
+	if ref := schema.Ref.String(); strings.HasPrefix(ref, definitionsRefPrefix) {
+		callback(strings.TrimPrefix(ref, definitionsRefPrefix))
+	}
+
+	for _, property := range schema.Properties {
+		collectRefs(&property, callback)
+	}
+	for i := range schema.AllOf {
+		collectRefs(&schema.AllOf[i], callback)
+	}
+	if schema.Items != nil {
+		if schema.Items.Schema != nil {
+			collectRefs(schema.Items.Schema, callback)
+		}
+		for i := range schema.Items.Schemas {
+			collectRefs(&schema.Items.Schemas[i], callback)
+		}
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		collectRefs(schema.AdditionalProperties.Schema, callback)
+	}
+}
+
+// newSwagger returns an empty swagger document that can be processed by
+// `split.NewRefactoringPlan`.
+func newSwagger(kubernetesVersion string) *openapi_spec.Swagger {
+	swagger := openapi_spec.Swagger{}
+	swagger.Swagger = swaggerVersion
+	swagger.Paths = &openapi_spec.Paths{}
+	swagger.Info = &openapi_spec.Info{}
+	swagger.Info.Title = swaggerTitle
+	swagger.Info.Version = kubernetesVersion
+	swagger.Definitions = make(openapi_spec.Definitions)
+
+	return &swagger
+}
+
+// normalizeSchema rewrites the OpenAPI v3 constructs that cannot be handled by
+// the model emitter into their OpenAPI v2 equivalent.
+func normalizeSchema(schema *openapi_spec.Schema) {
+	if schema.Nullable {
+		schema.AddExtension("x-nullable", true)
+		schema.Nullable = false
+	}
+
+	// A value that can be of different primitive types (like `IntOrString`
+	// or `Quantity`) is handled as a string, like the OpenAPI v2 document
+	// of Kubernetes does
+	if len(schema.Type) == 0 && (hasOnlyPrimitiveTypes(schema.OneOf) || hasOnlyPrimitiveTypes(schema.AnyOf)) {
+		schema.Type = openapi_spec.StringOrArray{typeString}
+	}
+
+	// oneOf, anyOf and not are used only for validation purposes. The emitter
+	// would generate invalid code out of them.
+	schema.OneOf = nil
+	schema.AnyOf = nil
+	schema.Not = nil
+
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema == nil {
+		// `additionalProperties: true` is the same as a free-form object
+		schema.AdditionalProperties = nil
+	}
+}
+
+func hasOnlyPrimitiveTypes(schemas []openapi_spec.Schema) bool {
+	if len(schemas) == 0 {
+		return false
+	}
+	for _, schema := range schemas {
+		if len(schema.Type) != 1 || !slices.Contains(primitiveTypes, schema.Type[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasExtension returns true when the boolean extension is set to true.
+func hasExtension(schema *openapi_spec.Schema, name string) bool {
+	value, found := schema.Extensions.GetBool(name)
+	return found && value
+}
+
+func isObject(schema *openapi_spec.Schema) bool {
+	return schema.Type.Contains(typeObject) || (len(schema.Type) == 0 && len(schema.Properties) > 0)
+}
+
+// inlineSchemaHoister moves all the object schemas that are declared inline
+// into dedicated definitions. The upstream Kubernetes swagger file never
+// declares objects inline, hence the generator expects all the objects to be
+// referenced by `$ref`.
+type inlineSchemaHoister struct {
+	// prefix of the ids of the definitions created by the hoister, for example
+	// `io.cert-manager.v1.`
+	idPrefix    string
+	definitions openapi_spec.Definitions
+}
+
+func newInlineSchemaHoister(idPrefix string, definitions openapi_spec.Definitions) *inlineSchemaHoister {
+	return &inlineSchemaHoister{
+		idPrefix:    idPrefix,
+		definitions: definitions,
+	}
+}
+
+// AddDefinition registers the schema as a definition named `typeName`, all
+// the object schemas it declares inline are moved into their own definitions.
+// The id of the definition is returned.
+func (h *inlineSchemaHoister) AddDefinition(typeName string, schema openapi_spec.Schema) (string, error) {
+	id := h.idPrefix + typeName
+	if _, known := h.definitions[id]; known {
+		return "", fmt.Errorf("definition %s is declared more than once", id)
+	}
+	// reserve the id before processing the properties, this avoids
+	// collisions with the definitions hoisted from them
+	h.definitions[id] = schema
+
+	normalizeSchema(&schema)
+
+	if schema.Items != nil && schema.Items.Schema != nil {
+		items, err := h.hoist(typeName+"Item", *schema.Items.Schema)
+		if err != nil {
+			return "", err
+		}
+		schema.Items.Schema = &items
+	}
+
+	if schema.AdditionalProperties != nil {
+		value, err := h.hoist(typeName+"Value", *schema.AdditionalProperties.Schema)
+		if err != nil {
+			return "", err
+		}
+		schema.AdditionalProperties.Schema = &value
+	}
+
+	// process properties in a stable order, this ensures the names of the
+	// hoisted definitions do not change between runs
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, err := h.hoist(typeName+strcase.ToCamel(name), schema.Properties[name])
+		if err != nil {
+			return "", err
+		}
+		schema.Properties[name] = property
+	}
+
+	h.definitions[id] = schema
+	return id, nil
+}
+
+// hoist returns the schema that has to be used in place of the given one.
+// Object schemas are turned into references to a new definition called
+// `typeName`, arrays and maps are processed recursively.
+func (h *inlineSchemaHoister) hoist(typeName string, schema openapi_spec.Schema) (openapi_spec.Schema, error) {
+	normalizeSchema(&schema)
+
+	if hasExtension(&schema, kubernetesIntOrStringKey) {
+		return refSchema(intOrStringID, schema.Description)
+	}
+
+	if hasExtension(&schema, kubernetesEmbeddedResourceKey) || hasExtension(&schema, kubernetesPreserveUnknownFields) {
+		if len(schema.Properties) == 0 {
+			// free-form object, it is going to be handled as an interface
+			schema.Type = openapi_spec.StringOrArray{typeObject}
+		}
+	}
+
+	switch {
+	case schema.Ref.String() != "":
+		return schema, nil
+	case schema.Type.Contains(typeArray):
+		if schema.Items != nil && schema.Items.Schema != nil {
+			items, err := h.hoist(typeName, *schema.Items.Schema)
+			if err != nil {
+				return openapi_spec.Schema{}, err
+			}
+			schema.Items.Schema = &items
+		}
+		return schema, nil
+	case isObject(&schema) && len(schema.Properties) == 0 && schema.AdditionalProperties != nil:
+		// this is a map
+		value, err := h.hoist(typeName, *schema.AdditionalProperties.Schema)
+		if err != nil {
+			return openapi_spec.Schema{}, err
+		}
+		schema.AdditionalProperties.Schema = &value
+		return schema, nil
+	case isObject(&schema):
+		id, err := h.AddDefinition(h.uniqueTypeName(typeName), schema)
+		if err != nil {
+			return openapi_spec.Schema{}, err
+		}
+		return refSchema(id, schema.Description)
+	default:
+		return schema, nil
+	}
+}
+
+// uniqueTypeName returns a type name that is not used by any other
+// definition.
+func (h *inlineSchemaHoister) uniqueTypeName(typeName string) string {
+	candidate := typeName
+	for counter := firstCollisionSuffix; ; counter++ {
+		if _, known := h.definitions[h.idPrefix+candidate]; !known {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", typeName, counter)
+	}
+}
+
+// refSchema returns a schema referencing the definition with the given id.
+func refSchema(id, description string) (openapi_spec.Schema, error) {
+	ref, err := openapi_spec.NewRef(definitionsRefPrefix + id)
+	if err != nil {
+		return openapi_spec.Schema{}, errors.Wrapf(err, "cannot create ref to %s", id)
+	}
+
+	schema := openapi_spec.Schema{}
+	schema.Ref = ref
+	schema.Description = description
+
+	return schema, nil
+}
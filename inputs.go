@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubewarden/k8s-objects-generator/input"
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+// inputFlags holds the flags that define the OpenAPI documents to process.
+// All the inputs are merged together.
+type inputFlags struct {
+	swaggerFiles  stringSliceFlag
+	openAPIv3Path string
+	kubeVersion   string
+	crdPaths      stringSliceFlag
+	download      downloadFlags
+	cluster       clusterFlags
+}
+
+func (i *inputFlags) register(flags *flag.FlagSet) {
+	flags.Var(&i.swaggerFiles, "f", "The swagger file to process. Can be repeated")
+	flags.StringVar(&i.openAPIv3Path, "openapi-v3", "", "The OpenAPI v3 document, or the directory containing the per group-version documents, to process")
+	flags.StringVar(&i.kubeVersion, "kube-version", "", "Fetch the swagger file of the specified Kubernetes version, e.g. `1.33`, `1.34.0-rc.1`, or of a git ref of the Kubernetes repository, e.g. `release-1.33`, `master`")
+	flags.Var(&i.crdPaths, "crd", "A CustomResourceDefinition file, or a directory containing them, to process. Can be repeated")
+	i.download.register(flags)
+	i.cluster.register(flags)
+}
+
+// specified returns true when at least one input has been specified.
+func (i *inputFlags) specified() bool {
+	return len(i.swaggerFiles) > 0 || i.openAPIv3Path != "" || i.kubeVersion != "" || len(i.crdPaths) > 0 || i.cluster.enabled
+}
+
+func (i *inputFlags) validate() error {
+	if !i.specified() {
+		return errors.New("at least one of the `-f`, `-openapi-v3`, `-kube-version`, `-crd` or `-from-cluster` flags must be specified")
+	}
+	return nil
+}
+
+// fetch reads, or downloads, all the inputs.
+func (i *inputFlags) fetch() ([]input.SwaggerInput, error) {
+	inputs := []input.SwaggerInput{}
+
+	if i.kubeVersion != "" {
+		downloader, err := i.download.newDownloader()
+		if err != nil {
+			return nil, err
+		}
+		swaggerData, err := downloader.Download(i.kubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		swaggerInput, err := input.NewSwaggerInput("Kubernetes "+swaggerData.KubernetesVersion, swaggerData)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, swaggerInput)
+	}
+
+	for _, swaggerFile := range i.swaggerFiles {
+		data, err := os.ReadFile(swaggerFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read swagger file %s", swaggerFile)
+		}
+		swaggerInput, err := input.NewSwaggerInput(swaggerFile, &input.SwaggerData{
+			Data:              data,
+			KubernetesVersion: unknownKubernetesVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, swaggerInput)
+	}
+
+	if i.cluster.enabled {
+		swaggerData, err := i.cluster.newSource().Fetch()
+		if err != nil {
+			return nil, err
+		}
+		swaggerInput, err := input.NewSwaggerInput(i.cluster.source(), swaggerData)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, swaggerInput)
+	}
+
+	if i.openAPIv3Path != "" {
+		swagger, err := input.SwaggerFromOpenAPIv3(i.openAPIv3Path)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input.SwaggerInput{Source: i.openAPIv3Path, Swagger: swagger})
+	}
+
+	for _, crdPath := range i.crdPaths {
+		swagger, err := input.SwaggerFromCRDs(crdPath)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input.SwaggerInput{Source: crdPath, Swagger: swagger})
+	}
+
+	return inputs, nil
+}
+
+// fetchSwaggerData fetches all the inputs and merges them into a single
+// swagger document.
+func fetchSwaggerData(inputs *inputFlags) *input.MergedSwagger {
+	swaggerInputs, err := inputs.fetch()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	merged, err := input.MergeSwaggers(swaggerInputs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return merged
+}
+
+// applyInputsToPlan records the Kubernetes version and the sources of the
+// inputs inside of the refactoring plan.
+func applyInputsToPlan(plan *split.RefactoringPlan, kubernetesVersion string, definitionSources map[string][]string) {
+	// the swagger files of Kubernetes report `unversioned`, use the exact
+	// version or git ref that has been fetched instead
+	if kubernetesVersion != unknownKubernetesVersion {
+		plan.KubernetesVersion = kubernetesVersion
+	}
+	plan.AssignSources(definitionSources)
+}
+
+// reportSources logs the inputs each package comes from.
+func reportSources(plan *split.RefactoringPlan) {
+	packages := make([]string, 0, len(plan.Sources))
+	for pkgName := range plan.Sources {
+		packages = append(packages, pkgName)
+	}
+	slices.Sort(packages)
+
+	for _, pkgName := range packages {
+		slog.Info("Package sources", "package", pkgName, "sources", strings.Join(plan.Sources[pkgName], ", "))
+	}
+}
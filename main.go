@@ -4,65 +4,174 @@ import (
 	_ "embed"
 	"flag"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 
+	"github.com/kubewarden/k8s-objects-generator/config"
+	"github.com/kubewarden/k8s-objects-generator/input"
 	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
 )
 
+// unknownKubernetesVersion is recorded when the input doesn't come from a
+// known Kubernetes version.
+const unknownKubernetesVersion = "unknown"
+
 //go:embed LICENSE
 var LICENSE string
 
+// stringSliceFlag is a flag that can be specified multiple times.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func main() {
-	var swaggerFile, kubeVersion, outputDir, gitRepo string
-	flag.StringVar(&swaggerFile, "f", "", "The swagger file to process")
-	flag.StringVar(&kubeVersion, "kube-version", "", "Fetch the swagger file of the specified Kubernetes version")
+	if len(os.Args) > 1 {
+		var command func(args []string) error
+		switch os.Args[1] {
+		case "cache":
+			command = runCacheCommand
+		case "plan":
+			command = runPlanCommand
+		case "generate-range":
+			command = runGenerateRangeCommand
+		case "verify-manifest":
+			command = runVerifyManifestCommand
+		}
+		if command != nil {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	var outputDir, gitRepo, moduleDir, packageMappingFile, layoutName string
+	var jobs int
+	var force, noVerify, check bool
+	var inputs inputFlags
+	var modelCache modelCacheFlags
+	var selection selectionFlags
+	var configFile configFlags
+	var overlay overlayFlags
+	configFile.register(flag.CommandLine)
+	overlay.register(flag.CommandLine)
+	inputs.register(flag.CommandLine)
+	modelCache.register(flag.CommandLine)
+	selection.register(flag.CommandLine)
 	flag.StringVar(&outputDir, "o", "./k8s-objects", "The root directory where the files will be generated")
 	flag.StringVar(&gitRepo, "repo", "github.com/kubewarden/k8s-objects", "The repository where the generated files are going to be published")
+	flag.StringVar(&layoutName, "layout", string(split.LayoutGOPATH), "Where the module is placed inside of the output directory: `gopath` places it inside of `src/<repo>`, `module` makes the output directory the root of the module")
+	flag.StringVar(&moduleDir, "module-dir", "", "Generate the packages inside of this directory of an existing Go module, instead of creating a new module. Cannot be used together with `-o`, `-repo` and `-layout`")
+	flag.StringVar(&packageMappingFile, "package-mapping", "", "YAML file holding the rules that map the definition ids to Go packages")
+	flag.IntVar(&jobs, "j", runtime.NumCPU(), "Number of packages generated concurrently")
+	flag.BoolVar(&noVerify, "no-verify", false, "Skip the build and the vet of the generated packages")
+	flag.BoolVar(&check, "check", false, "Compare the output directory with the files that would be generated, printing their differences, without changing it. Exits with status 1 when they differ. Defaults to the Kubernetes version recorded inside of the output directory when no input is specified")
+	flag.BoolVar(&force, "force", false, "Replace the output directory even when it has not been created by k8s-objects-generator")
 	flag.Parse()
 
-	validateFlags(swaggerFile, kubeVersion)
-
-	swaggerData := fetchSwaggerData(swaggerFile, kubeVersion)
-	outputDir = resolveOutputDir(outputDir)
-
-	templatesTmpDir := createTemplatesDir()
-	defer cleanupTemplatesDir(templatesTmpDir)
-
-	writeTemplatesOrPanic(templatesTmpDir)
+	cfg, err := configFile.apply(flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if jobs < 1 {
+		log.Fatal("-j must be at least 1")
+	}
+	if moduleDir != "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "o" || f.Name == "repo" || f.Name == "layout" {
+				log.Fatalf("-%s cannot be used together with -module-dir", f.Name)
+			}
+		})
+	}
+	layout, err := split.ParseLayout(layoutName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	project := newProject(resolveOutputDir(outputDir), gitRepo, moduleDir, layout, cfg.GoModule(), force)
+	var drift *driftCheck
+	if check {
+		if drift, err = newDriftCheck(project, &inputs); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := inputs.validate(); err != nil {
+		log.Fatal(err)
+	}
+	packageMapping := loadPackageMapping(packageMappingFile, cfg.PackageMapping)
+	overlays, err := overlay.overlays(cfg.Output.Overlays)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cache, err := modelCache.newModelCache(inputs.download.cacheDir)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	project := initializeProject(outputDir, gitRepo, templatesTmpDir, swaggerData)
-	generateSwaggerFiles(project)
-}
+	merged := fetchSwaggerData(&inputs)
+	swaggerData, err := merged.SwaggerData()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-func validateFlags(swaggerFile, kubeVersion string) {
-	if swaggerFile != "" && kubeVersion != "" {
-		log.Fatal("`-f` and `-kube-version` flags cannot be used at the same time")
+	initializeProject(project, swaggerData)
+	refactoringPlan := generateSwaggerFiles(project, cfg, packageMapping, &selection, swaggerData.KubernetesVersion, merged.Sources, jobs, cache)
+	handWritten := applyOverlays(project, overlays)
+	importRules := split.ImportRules{Dependencies: cfg.Dependencies(), HandWritten: handWritten}
+	if err := split.CheckModuleImports(afero.NewOsFs(), project.Root, project.GitRepo, importRules); err != nil {
+		log.Fatal(err)
+	}
+	tidyProject(project)
+	if !noVerify {
+		verifyProject(project, refactoringPlan)
+	}
+	baseDir, err := configFile.baseDir()
+	if err != nil {
+		log.Fatal(err)
 	}
-	if len(swaggerFile) == 0 && len(kubeVersion) == 0 {
-		log.Fatal("one of the `-f` or `-kube-version` flag must be specified")
+	writeManifest(project, cfg, packageMapping, &selection, overlays, merged.Inputs, baseDir)
+	if err := project.Commit(); err != nil {
+		log.Fatal(err)
 	}
+	if drift != nil {
+		drift.compare(project)
+		return
+	}
+	slog.Info("Generated files written", "path", project.Root)
 }
 
-func fetchSwaggerData(swaggerFile, kubeVersion string) *SwaggerData {
-	if kubeVersion != "" {
-		swaggerData, err := DownloadSwagger(kubeVersion)
+// loadPackageMapping returns the mapping defined by the file, or by the rules
+// of the configuration file when no file is given.
+func loadPackageMapping(packageMappingFile string, configRules []swaggerhelpers.PackageMappingRule) *swaggerhelpers.PackageMapping {
+	if packageMappingFile == "" {
+		if len(configRules) == 0 {
+			return swaggerhelpers.DefaultPackageMapping()
+		}
+		packageMapping, err := swaggerhelpers.NewPackageMapping(append(configRules, swaggerhelpers.DefaultPackageMappingRules()...))
 		if err != nil {
 			log.Fatal(err)
 		}
-		return swaggerData
+		return packageMapping
 	}
-	data, err := os.ReadFile(swaggerFile)
+
+	packageMapping, err := swaggerhelpers.LoadPackageMapping(packageMappingFile)
 	if err != nil {
-		log.Fatalf("cannot read swagger file %s: %v", swaggerFile, err)
-	}
-	return &SwaggerData{
-		Data:              data,
-		KubernetesVersion: "unknown",
+		log.Fatal(err)
 	}
+	return packageMapping
 }
 
 func resolveOutputDir(outputDir string) string {
@@ -73,61 +182,115 @@ func resolveOutputDir(outputDir string) string {
 	return absOutputDir
 }
 
-func createTemplatesDir() string {
-	templatesTmpDir, err := os.MkdirTemp("", "k8s-objects-generator-swagger-templates")
-	if err != nil {
-		log.Panic(err)
-	}
-	log.Printf("Created templates at %s", templatesTmpDir)
-	return templatesTmpDir
-}
-
-func cleanupTemplatesDir(templatesTmpDir string) {
-	if err := os.RemoveAll(templatesTmpDir); err != nil {
-		log.Printf("error removing the temporary directory that holds swagger templates '%s': %v", templatesTmpDir, err)
+// newProject returns the project generating the packages inside of the
+// output directory, or inside of the directory of an existing module.
+func newProject(outputDir, gitRepo, moduleDir string, layout split.Layout, goModule split.GoModule, force bool) *split.Project {
+	var project split.Project
+	var err error
+	if moduleDir != "" {
+		project, err = split.NewModuleProject(moduleDir)
+	} else {
+		project, err = split.NewProject(outputDir, gitRepo, layout)
+		project.GoModule = goModule
 	}
-}
-
-func writeTemplatesOrPanic(templatesTmpDir string) {
-	if err := writeTemplates(templatesTmpDir); err != nil {
-		log.Panic(err)
+	if err != nil {
+		log.Fatal(err)
 	}
+	project.Force = force
+	return &project
 }
 
-func initializeProject(outputDir, gitRepo, templatesTmpDir string, swaggerData *SwaggerData) *split.Project {
-	project, err := split.NewProject(outputDir, gitRepo, filepath.Join(templatesTmpDir, "swagger_templates"))
-	if err != nil {
-		log.Panic(err)
-	}
+// initializeProject prepares the staging directory of the project, the
+// generated files are moved into the output directory by Commit.
+func initializeProject(project *split.Project, swaggerData *input.SwaggerData) {
 	log.Print("Initializing target directory")
-	err = project.Init(swaggerData.Data, swaggerData.KubernetesVersion, LICENSE)
-	if err != nil {
-		log.Panic(err)
+	if err := project.Init(swaggerData.Data, swaggerData.KubernetesVersion, LICENSE); err != nil {
+		log.Fatal(err)
 	}
-	return &project
 }
 
-func generateSwaggerFiles(project *split.Project) {
+func generateSwaggerFiles(project *split.Project, cfg *config.Config, packageMapping *swaggerhelpers.PackageMapping, selection *selectionFlags, kubernetesVersion string, definitionSources map[string][]string, jobs int, cache *split.ModelCache) *split.RefactoringPlan {
 	splitter, err := split.NewSplitter(project.SwaggerFile())
 	if err != nil {
 		log.Panic(err)
 	}
 
-	refactoringPlan, err := splitter.ComputeRefactoringPlan()
+	refactoringPlan, err := splitter.ComputeRefactoringPlan(packageMapping, cfg.Patches)
 	if err != nil {
 		log.Panic(err)
 	}
+	refactoringPlan.OverrideTypes(cfg.DefinitionTypeOverrides())
+	if err := selection.apply(refactoringPlan); err != nil {
+		log.Fatal(err)
+	}
+	applyInputsToPlan(refactoringPlan, kubernetesVersion, definitionSources)
+	reportSources(refactoringPlan)
+	reportPatches(refactoringPlan)
 
-	if err := splitter.GenerateSwaggerFiles(*project, refactoringPlan); err != nil {
+	if err := splitter.GenerateSwaggerFiles(*project, refactoringPlan, jobs, cache, cfg.EmitterOptions()); err != nil {
 		log.Panic(err)
 	}
+	reportModelCacheStats(cache)
 
 	groupResource := split.NewGroupResource(afero.NewOsFs())
 	if err := groupResource.Generate(*project, refactoringPlan); err != nil {
 		log.Panic(err)
 	}
+	return refactoringPlan
+}
+
+// applyOverlays merges the overlays into the generated packages, the copied
+// files are returned.
+func applyOverlays(project *split.Project, overlays []split.Overlay) []string {
+	if len(overlays) == 0 {
+		return nil
+	}
 
+	report, err := split.ApplyOverlays(afero.NewOsFs(), project.Root, overlays)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report.Log()
+	return report.Files()
+}
+
+// tidyProject adds the dependencies of the generated packages to go.mod.
+func tidyProject(project *split.Project) {
+	if project.ExistingModule {
+		slog.Info("Packages generated inside of an existing module, run `go mod tidy` to add their dependencies", "path", project.GitRepo)
+		return
+	}
 	if err := project.RunGoModTidy(); err != nil {
 		log.Panic(errors.Wrap(err, "error running go mod tidy"))
 	}
 }
+
+// verifyProject builds and vets the generated packages, the files are not
+// moved into the output directory when they fail.
+func verifyProject(project *split.Project, plan *split.RefactoringPlan) {
+	if project.ExistingModule {
+		slog.Info("Packages generated inside of an existing module, they are not verified", "path", project.GitRepo)
+		return
+	}
+
+	log.Print("Verifying the generated packages")
+	if err := project.Verify(plan); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// reportPatches logs how many schemas each patch changed.
+func reportPatches(plan *split.RefactoringPlan) {
+	counts := map[string]int{}
+	names := []string{}
+	for _, match := range plan.Patches {
+		if counts[match.Rule] == 0 {
+			names = append(names, match.Rule)
+		}
+		counts[match.Rule]++
+	}
+
+	for _, name := range names {
+		slog.Info("Patch applied", "patch", name, "schemas", counts[name])
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubewarden/k8s-objects-generator/config"
+	"github.com/kubewarden/k8s-objects-generator/emitter"
+	"github.com/kubewarden/k8s-objects-generator/input"
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+// manifestConfiguration is the effective configuration recorded inside of
+// the manifest. Only the options changing the generated files are part of
+// it, the go.mod settings are recorded by the metadata.
+type manifestConfiguration struct {
+	ModulePath string `json:"modulePath"`
+	// Layout is empty when generating inside of an existing module
+	Layout         string                              `json:"layout,omitempty"`
+	PackageMapping []swaggerhelpers.PackageMappingRule `json:"packageMapping"`
+	Selection      config.Selection                    `json:"selection"`
+	Initialisms    []string                            `json:"initialisms,omitempty"`
+	Patches        []swaggerhelpers.DefinitionPatch    `json:"patches,omitempty"`
+	TypeOverrides  []config.TypeOverride               `json:"typeOverrides,omitempty"`
+	Overlays       []config.Overlay                    `json:"overlays,omitempty"`
+}
+
+// writeManifest records the provenance of the generated files, and their
+// checksums, inside of the metadata file of the project. The paths of the
+// inputs and of the overlays are recorded relative to `baseDir`, the
+// directory of the configuration file, so that the manifest doesn't depend on
+// the location of the checkout. Only their base name is recorded when there's
+// no configuration file, the inputs are identified by their digest.
+func writeManifest(project *split.Project, cfg *config.Config, packageMapping *swaggerhelpers.PackageMapping, selection *selectionFlags, overlays []split.Overlay, inputs []input.InputDigest, baseDir string) {
+	configuration := manifestConfiguration{
+		ModulePath:     project.GitRepo,
+		PackageMapping: packageMapping.Rules(),
+		Selection: config.Selection{
+			Include: selection.include,
+			Exclude: selection.exclude,
+		},
+		Initialisms:   cfg.Initialisms,
+		Patches:       cfg.Patches,
+		TypeOverrides: cfg.TypeOverrides,
+	}
+	if !project.ExistingModule {
+		configuration.Layout = string(project.Layout)
+	}
+	for _, overlay := range overlays {
+		configuration.Overlays = append(configuration.Overlays, config.Overlay{Dir: manifestPath(baseDir, overlay.Dir), Policy: string(overlay.Policy)})
+	}
+	configurationData, err := json.Marshal(configuration)
+	if err != nil {
+		log.Panic(errors.Wrap(err, "cannot encode the configuration"))
+	}
+
+	version, err := generatorVersion()
+	if err != nil {
+		log.Fatal(err)
+	}
+	provenance := split.Provenance{
+		GeneratorVersion: version,
+		EmitterVersion:   emitter.Version,
+		Inputs:           make([]split.ManifestInput, 0, len(inputs)),
+		Configuration:    configurationData,
+	}
+	for _, digest := range inputs {
+		provenance.Inputs = append(provenance.Inputs, split.ManifestInput{Source: manifestPath(baseDir, digest.Source), SHA256: digest.SHA256})
+	}
+
+	if err := project.WriteManifest(provenance); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// manifestPath returns the path recorded by the manifest for an input, or an
+// overlay: the path relative to `baseDir`, with slashes, or the base name
+// when `baseDir` is empty. The sources that are not paths, like the
+// Kubernetes versions, are returned untouched.
+func manifestPath(baseDir, path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return path
+	}
+	if baseDir != "" {
+		if absPath, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(baseDir, absPath); err == nil {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+const verifyManifestCommandUsage = `Usage: k8s-objects-generator verify-manifest [dir]
+
+Compare the files generated inside of the directory with the checksums
+recorded by its manifest, the metadata.json file, and list the ones that have
+been modified, removed or added since the generation. The directory is the
+root of the generated packages, it defaults to the working directory. Exits
+with status 1 when a file doesn't match the manifest.
+`
+
+// runVerifyManifestCommand implements the `verify-manifest` subcommand.
+func runVerifyManifestCommand(args []string) error {
+	flags := flag.NewFlagSet("verify-manifest", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), verifyManifestCommandUsage)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	dir := "."
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		dir = flags.Arg(0)
+	default:
+		flags.Usage()
+		return errors.New("only one directory can be verified")
+	}
+
+	mismatch, err := split.VerifyManifest(afero.NewOsFs(), dir)
+	if err != nil {
+		return err
+	}
+	if !mismatch.Empty() {
+		fmt.Println(mismatch)
+		return fmt.Errorf("%d files of %s don't match the manifest",
+			len(mismatch.Modified)+len(mismatch.Missing)+len(mismatch.Added), dir)
+	}
+
+	slog.Info("All the files match the manifest", "path", dir)
+	return nil
+}
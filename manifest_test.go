@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/config"
+	"github.com/kubewarden/k8s-objects-generator/input"
+	"github.com/kubewarden/k8s-objects-generator/split"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+func TestManifestPath(t *testing.T) {
+	baseDir := t.TempDir()
+	swaggerFile := filepath.Join(baseDir, "specs", "swagger.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(swaggerFile), 0o750))
+	require.NoError(t, os.WriteFile(swaggerFile, []byte("{}"), 0o600))
+	overlayDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		baseDir  string
+		path     string
+		expected string
+	}{
+		{name: "input file", baseDir: baseDir, path: swaggerFile, expected: "specs/swagger.json"},
+		{name: "overlay outside of the base dir", baseDir: filepath.Dir(overlayDir), path: overlayDir, expected: filepath.Base(overlayDir)},
+		{name: "no base dir", path: swaggerFile, expected: "swagger.json"},
+		{name: "Kubernetes version", baseDir: baseDir, path: "Kubernetes 1.33.0", expected: "Kubernetes 1.33.0"},
+		{name: "cluster", path: "cluster (context kind/dev)", expected: "cluster (context kind/dev)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, manifestPath(test.baseDir, test.path))
+		})
+	}
+}
+
+func TestConfigBaseDir(t *testing.T) {
+	configFile := configFlags{}
+	baseDir, err := configFile.baseDir()
+	require.NoError(t, err)
+	assert.Empty(t, baseDir)
+
+	configDir := t.TempDir()
+	configFile = configFlags{path: filepath.Join(configDir, "k8s-objects-generator.yaml")}
+	require.NoError(t, os.WriteFile(configFile.path, []byte("version: 1\n"), 0o600))
+	_, err = configFile.apply(flag.NewFlagSet("test", flag.ContinueOnError))
+	require.NoError(t, err)
+	baseDir, err = configFile.baseDir()
+	require.NoError(t, err)
+	assert.Equal(t, configDir, baseDir)
+}
+
+func TestWriteManifestFromDifferentWorkingDirectories(t *testing.T) {
+	inputsDir := t.TempDir()
+	crdsDir := filepath.Join(inputsDir, "crds")
+	require.NoError(t, os.MkdirAll(crdsDir, 0o750))
+
+	writeFrom := func(workDir, crdsPath string) []byte {
+		t.Chdir(workDir)
+		project, err := split.NewProject(t.TempDir(), "example.com/objects", split.LayoutModule)
+		require.NoError(t, err)
+		require.NoError(t, project.Init([]byte("{}"), "unknown", "license"))
+		writeManifest(&project, &config.Config{}, swaggerhelpers.DefaultPackageMapping(), &selectionFlags{},
+			[]split.Overlay{{Dir: crdsPath, Policy: split.OverlayAdd}},
+			[]input.InputDigest{{Source: crdsPath, SHA256: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"}}, "")
+		data, err := os.ReadFile(filepath.Join(project.Root, split.MetadataFileName))
+		require.NoError(t, err)
+		return data
+	}
+
+	fromInputsDir := writeFrom(inputsDir, "crds")
+	fromElsewhere := writeFrom(t.TempDir(), crdsDir)
+	assert.Equal(t, string(fromInputsDir), string(fromElsewhere))
+	assert.Contains(t, string(fromInputsDir), `"source": "crds"`)
+}
@@ -58,32 +58,55 @@ if [ -z "$GIT_COMMIT_MSG_FILE" ]; then
 fi
 
 make build
+
+cd "$GIT_DIR"
+git checkout main
+upstream=$(git rev-parse --abbrev-ref --symbolic-full-name '@{u}' | awk -F "/" '{print $1}')
+git fetch "$upstream"
+cd -
+
+# each version is generated straight into its own worktree of the checkout,
+# like $OUT_DIR/1.33, on the branch of the version
+mkdir -p "$OUT_DIR"
+for KUBEMINOR in $(eval "echo {$KUBERNETES_VERSION_MIN..$KUBERNETES_VERSION_MAX}"); do
+  BRANCH=release-1.$KUBEMINOR
+  WORKTREE="$OUT_DIR/1.$KUBEMINOR"
+
+  # the worktree of a previous run is replaced
+  git -C "$GIT_DIR" worktree remove --force "$WORKTREE" 2>/dev/null || true
+  rm -rf "$WORKTREE"
+  git -C "$GIT_DIR" worktree prune
+
+  if [ $((n = $(git -C "$GIT_DIR" branch -r | grep -wic "$BRANCH"))) -gt 0 ]; then
+    git -C "$GIT_DIR" worktree add "$WORKTREE" "$BRANCH"
+    git -C "$WORKTREE" rebase "$upstream"/"$BRANCH" "$BRANCH"
+  else
+    git -C "$GIT_DIR" worktree add --detach "$WORKTREE"
+    git -C "$WORKTREE" checkout --orphan "$BRANCH"
+  fi
+done
+
+# all the versions are generated upfront, the generation of the whole range
+# fails when one of them cannot be generated. The generated module replaces
+# the content of each worktree, but its .git. The worktree of a new branch
+# holds the files of main, hence -force
+./k8s-objects-generator generate-range -from "1.$KUBERNETES_VERSION_MIN" -to "1.$KUBERNETES_VERSION_MAX" -o "$OUT_DIR" -- -layout module -force
+
 for KUBEMINOR in $(eval "echo {$KUBERNETES_VERSION_MIN..$KUBERNETES_VERSION_MAX}"); do
   echo ==================================
   echo PROCESSING KUBERNETES "1.$KUBEMINOR"
   echo ==================================
 
-  ./k8s-objects-generator -kube-version "1.$KUBEMINOR" -o "$OUT_DIR"
-
   BRANCH=release-1.$KUBEMINOR
 
-  cd "$GIT_DIR"
-  git checkout main
-  upstream=$(git rev-parse --abbrev-ref --symbolic-full-name '@{u}' | awk -F "/" '{print $1}')
-  git fetch "$upstream"
-
+  cd "$OUT_DIR/1.$KUBEMINOR"
   if [ $((n = $(git branch -r | grep -wic "$BRANCH"))) -gt 0 ]; then
-    git checkout "$BRANCH"
-    git rebase "$upstream"/"$BRANCH" "$BRANCH"
-
     n=$(git tag | grep -wic "v1.$KUBEMINOR")
 
     GIT_TAG="v1.$KUBEMINOR.0-kw$((n + 1))"
   else
-    git checkout --orphan "$BRANCH"
     GIT_TAG="v1.$KUBEMINOR.0-kw1"
   fi
-  rsync -av --exclude '.git' --delete-after "$OUT_DIR"/src/github.com/kubewarden/k8s-objects/ "$GIT_DIR"
   golangci-lint run ./...
   # We need to check if there are changes before committing. Otherwise, 
   # we will try to create a empty commit and the script will fail.
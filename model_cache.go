@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/kubewarden/k8s-objects-generator/input"
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+// modelCacheFlags holds the flags that control the cache of the generated
+// models.
+type modelCacheFlags struct {
+	disabled bool
+}
+
+func (m *modelCacheFlags) register(flags *flag.FlagSet) {
+	flags.BoolVar(&m.disabled, "no-model-cache", false, "Generate the models of all the packages, without looking them up inside of the cache")
+}
+
+// newModelCache returns the cache of the models, stored inside of the cache
+// directory. Nil is returned when the cache is disabled.
+func (m *modelCacheFlags) newModelCache(cacheDir string) (*split.ModelCache, error) {
+	if m.disabled {
+		return nil, nil //nolint:nilnil // a nil cache disables caching
+	}
+
+	if cacheDir == "" {
+		defaultCacheDir, err := input.DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir = defaultCacheDir
+	}
+
+	version, err := generatorVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return split.NewModelCache(filepath.Join(cacheDir, "models"), version)
+}
+
+// reportModelCacheStats logs how many packages have been restored from the
+// cache.
+func reportModelCacheStats(cache *split.ModelCache) {
+	if cache == nil {
+		return
+	}
+
+	stats := cache.Stats()
+	slog.Info("Model cache", "hits", stats.Hits, "misses", stats.Misses)
+}
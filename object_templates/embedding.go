@@ -8,6 +8,10 @@ var GitIgnore string
 //go:embed README.md.tmpl
 var Readme string
 
+// StaticModulePath is the module path imported by the static files of
+// ApimachineryRoot, it's replaced by the path of the generated module.
+const StaticModulePath = "github.com/kubewarden/k8s-objects"
+
 //go:embed apimachinery/*
 var ApimachineryRoot embed.FS
 
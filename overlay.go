@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/kubewarden/k8s-objects-generator/config"
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+// overlayFlags holds the flags that define the directories merged into the
+// output directory after the generation.
+type overlayFlags struct {
+	dirs   stringSliceFlag
+	policy string
+}
+
+func (o *overlayFlags) register(flags *flag.FlagSet) {
+	flags.Var(&o.dirs, "overlay", "Directory of hand-written files merged into the output directory after the generation, keeping their relative paths. Can be repeated")
+	flags.StringVar(&o.policy, "overlay-policy", string(split.OverlayFail),
+		"What happens when a file of an overlay already exists: `replace` it, `add` only the new files, or `fail`")
+}
+
+// overlays returns the overlays defined by the flags or, when no `-overlay`
+// flag is given, by the configuration file.
+func (o *overlayFlags) overlays(configOverlays []config.Overlay) ([]split.Overlay, error) {
+	if len(o.dirs) == 0 {
+		overlays := make([]split.Overlay, 0, len(configOverlays))
+		for _, overlay := range configOverlays {
+			policy, err := split.ParseOverlayPolicy(overlay.Policy)
+			if err != nil {
+				return nil, err
+			}
+			overlays = append(overlays, split.Overlay{Dir: overlay.Dir, Policy: policy})
+		}
+		return overlays, nil
+	}
+
+	policy, err := split.ParseOverlayPolicy(o.policy)
+	if err != nil {
+		return nil, err
+	}
+	overlays := make([]split.Overlay, 0, len(o.dirs))
+	for _, dir := range o.dirs {
+		overlays = append(overlays, split.Overlay{Dir: dir, Policy: policy})
+	}
+	return overlays, nil
+}
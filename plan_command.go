@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+const planCommandUsage = `Usage: k8s-objects-generator plan [flags]
+
+Print how the inputs are going to be split into packages, without generating
+anything: the types, the interfaces generated as json.RawMessage, the
+Kubernetes kinds and the dependencies of each package.
+
+Flags:
+`
+
+const (
+	planFormatJSON  = "json"
+	planFormatTable = "table"
+)
+
+// runPlanCommand implements the `plan` subcommand.
+func runPlanCommand(args []string) error {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	var inputs inputFlags
+	var selection selectionFlags
+	var configFile configFlags
+	var packageMappingFile, format string
+	configFile.register(flags)
+	inputs.register(flags)
+	selection.register(flags)
+	flags.StringVar(&packageMappingFile, "package-mapping", "", "YAML file holding the rules that map the definition ids to Go packages")
+	flags.StringVar(&format, "format", planFormatTable, "Output format, either `json` or `table`")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), planCommandUsage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := configFile.apply(flags)
+	if err != nil {
+		return err
+	}
+	// the plan doesn't write anything, the cached swagger files are used but
+	// the downloaded ones are not added to the cache
+	inputs.download.readOnly = true
+	if err := inputs.validate(); err != nil {
+		return err
+	}
+	if format != planFormatJSON && format != planFormatTable {
+		return fmt.Errorf("unknown plan format '%s'", format)
+	}
+
+	packageMapping := loadPackageMapping(packageMappingFile, cfg.PackageMapping)
+	merged := fetchSwaggerData(&inputs)
+
+	plan, err := split.NewRefactoringPlan(merged.Swagger, packageMapping, cfg.Patches)
+	if err != nil {
+		return errors.Wrap(err, "cannot compute refactoring plan")
+	}
+	plan.OverrideTypes(cfg.DefinitionTypeOverrides())
+	if err := selection.apply(plan); err != nil {
+		return err
+	}
+	applyInputsToPlan(plan, merged.KubernetesVersion, merged.Sources)
+
+	return writePlanReport(os.Stdout, plan.Report(), format)
+}
+
+func writePlanReport(out io.Writer, report split.PlanReport, format string) error {
+	if format == planFormatJSON {
+		return report.WriteJSON(out)
+	}
+	return report.WriteTable(out)
+}
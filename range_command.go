@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const generateRangeCommandUsage = `Usage: k8s-objects-generator generate-range -from <version> -to <version> [flags] [-- generator flags]
+
+Generate the packages of a range of Kubernetes minor versions, like 1.14 to
+1.33. Each version is generated into its own directory of the output
+directory, named after the version, by a dedicated run of the generator. The
+runs share the swagger files, which are all downloaded upfront, and the cache
+of the models. The output of each run is written next to its directory, like
+1.33.log, and a summary of the outcome of each version is printed at the end.
+
+The first failure stops the other runs, unless -keep-going is set.
+
+The flags following -- are passed to each run of the generator, like
+-- -layout module -repo example.com/k8s-objects. The flags set by the
+subcommand itself, like -kube-version and -o, cannot be among them.
+
+Flags:
+`
+
+// minorVersionRegexp matches the bounds of the range, like `1.33`.
+var minorVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)$`) //nolint:gochecknoglobals // compiled once
+
+// logLineRegexp matches the lines written by the log package, like
+// `2025/04/23 10:12:01 cannot read swagger file`.
+var logLineRegexp = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} (.+)$`) //nolint:gochecknoglobals // compiled once
+
+// forwardedFlags are the flags of the generator set by the subcommand for
+// each run, they cannot be passed after `--`. The `-module-dir` flag would
+// replace the output directory of the run.
+var forwardedFlags = []string{"kube-version", "o", "module-dir", "offline", "j", "cache-dir", "swagger-lock"} //nolint:gochecknoglobals // this is a constant list
+
+// rangeFlags holds the flags of the `generate-range` subcommand.
+type rangeFlags struct {
+	download  downloadFlags
+	from      string
+	to        string
+	outputDir string
+	parallel  int
+	keepGoing bool
+	// generatorArgs are the flags passed to each run of the generator
+	generatorArgs []string
+}
+
+// rangeResult is the outcome of the generation of a version of the range.
+type rangeResult struct {
+	version  string
+	duration time.Duration
+	err      error
+	// stopped is true when the version has not been generated because of
+	// the failure of another one, or of an interruption
+	stopped bool
+}
+
+// runGenerateRangeCommand implements the `generate-range` subcommand.
+func runGenerateRangeCommand(args []string) error {
+	options, err := parseRangeFlags(args)
+	if err != nil {
+		return err
+	}
+	versions, err := minorVersionRange(options.from, options.to)
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "cannot find the executable of the generator")
+	}
+	if err := os.MkdirAll(options.outputDir, 0o750); err != nil {
+		return errors.Wrapf(err, "cannot create dir %s", options.outputDir)
+	}
+
+	results, err := downloadRange(options, versions)
+	if err != nil {
+		return err
+	}
+	// Ctrl-C stops the runs of the generator, and prints the summary
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	generateRange(ctx, executable, options, results)
+
+	if err := printRangeSummary(os.Stdout, options.outputDir, results); err != nil {
+		return err
+	}
+	return rangeError(results)
+}
+
+// parseRangeFlags parses the flags of the subcommand, the ones following
+// `--` are the flags of the generator.
+func parseRangeFlags(args []string) (*rangeFlags, error) {
+	flags := flag.NewFlagSet("generate-range", flag.ExitOnError)
+	var options rangeFlags
+	options.download.register(flags)
+	flags.StringVar(&options.from, "from", "", "The first Kubernetes minor version of the range, e.g. `1.14`")
+	flags.StringVar(&options.to, "to", "", "The last Kubernetes minor version of the range, e.g. `1.33`")
+	flags.StringVar(&options.outputDir, "o", "./k8s-objects", "The directory holding the output directory of each version")
+	flags.IntVar(&options.parallel, "parallel", max(1, runtime.NumCPU()/4), "Number of versions generated concurrently") //nolint:mnd // each run uses multiple cores
+	flags.BoolVar(&options.keepGoing, "keep-going", false, "Keep generating the other versions when one of them fails")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), generateRangeCommandUsage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if options.parallel < 1 {
+		return nil, errors.New("-parallel must be at least 1")
+	}
+	options.generatorArgs = flags.Args()
+	if err := checkGeneratorArgs(options.generatorArgs); err != nil {
+		return nil, err
+	}
+	return &options, nil
+}
+
+// checkGeneratorArgs rejects the flags of the generator set by the
+// subcommand.
+func checkGeneratorArgs(args []string) error {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if slices.Contains(forwardedFlags, name) {
+			return fmt.Errorf("the -%s flag cannot be passed to the runs of the generator, it's set by generate-range for each version", name)
+		}
+	}
+	return nil
+}
+
+// minorVersionRange returns the minor versions between the bounds, included.
+func minorVersionRange(from, to string) ([]string, error) {
+	if from == "" || to == "" {
+		return nil, errors.New("both -from and -to must be specified")
+	}
+	fromMajor, fromMinor, err := parseMinorVersion(from)
+	if err != nil {
+		return nil, err
+	}
+	toMajor, toMinor, err := parseMinorVersion(to)
+	if err != nil {
+		return nil, err
+	}
+	if fromMajor != toMajor {
+		return nil, fmt.Errorf("the range %s - %s spans different major versions", from, to)
+	}
+	if fromMinor > toMinor {
+		return nil, fmt.Errorf("the range %s - %s is empty", from, to)
+	}
+
+	versions := []string{}
+	for minor := fromMinor; minor <= toMinor; minor++ {
+		versions = append(versions, fmt.Sprintf("%d.%d", fromMajor, minor))
+	}
+	return versions, nil
+}
+
+func parseMinorVersion(version string) (int, int, error) {
+	match := minorVersionRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return 0, 0, fmt.Errorf("invalid Kubernetes minor version '%s', it must be like 1.33", version)
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return major, minor, nil
+}
+
+// downloadRange downloads the swagger files of the versions into the cache,
+// the runs of the generator use the cached ones. A version whose swagger file
+// cannot be downloaded is not generated, neither are the other ones unless
+// -keep-going is set.
+func downloadRange(options *rangeFlags, versions []string) ([]rangeResult, error) {
+	downloader, err := options.download.newDownloader()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]rangeResult, len(versions))
+	for i, version := range versions {
+		results[i].version = version
+	}
+	var failed error
+	for i := range results {
+		if _, err := downloader.Download(results[i].version); err != nil {
+			results[i].err = err
+			failed = fmt.Errorf("stopped after the failure of %s", results[i].version)
+			if !options.keepGoing {
+				break
+			}
+		}
+	}
+	if failed != nil && !options.keepGoing {
+		for i := range results {
+			if results[i].err == nil {
+				results[i].err, results[i].stopped = failed, true
+			}
+		}
+	}
+	return results, nil
+}
+
+// generateRange generates the versions of the results that have no error yet,
+// `parallel` at a time. The first failure cancels the other runs, unless
+// -keep-going is set.
+func generateRange(ctx context.Context, executable string, options *rangeFlags, results []rangeResult) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stopped := func(result *rangeResult) {
+		result.err, result.stopped = context.Cause(ctx), true
+		if errors.Is(result.err, context.Canceled) {
+			result.err = errors.New("interrupted")
+		}
+	}
+
+	pending := []int{}
+	for i, result := range results {
+		if result.err == nil {
+			pending = append(pending, i)
+		}
+	}
+
+	jobs := max(1, runtime.NumCPU()/options.parallel)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(options.parallel, len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				result := &results[i]
+				if ctx.Err() != nil {
+					stopped(result)
+					continue
+				}
+
+				outputDir := filepath.Join(options.outputDir, result.version)
+				args := append(runArgs(&options.download, result.version, outputDir, jobs), options.generatorArgs...)
+				start := time.Now()
+				result.err = runGenerator(ctx, executable, args, outputDir+".log")
+				result.duration = time.Since(start)
+				if result.err == nil {
+					continue
+				}
+				if ctx.Err() != nil {
+					stopped(result)
+					continue
+				}
+				if !options.keepGoing {
+					cancel(fmt.Errorf("stopped after the failure of %s", result.version))
+				}
+			}
+		}()
+	}
+	for _, i := range pending {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+}
+
+// runArgs returns the flags set by the subcommand for the run of the
+// generator generating the version.
+func runArgs(download *downloadFlags, version, outputDir string, jobs int) []string {
+	args := []string{"-kube-version", version, "-o", outputDir, "-offline", "-j", strconv.Itoa(jobs)}
+	if download.cacheDir != "" {
+		args = append(args, "-cache-dir", download.cacheDir)
+	}
+	// the cached swagger files are verified by each run too
+	if download.lockFile != "" {
+		args = append(args, "-swagger-lock", download.lockFile)
+	}
+	return args
+}
+
+// rangeError returns an error when at least one version has not been
+// generated.
+func rangeError(results []rangeResult) error {
+	failures := 0
+	for _, result := range results {
+		if result.err != nil {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d Kubernetes versions could not be generated", failures, len(results))
+	}
+	return nil
+}
+
+// runGenerator runs the generator with the given arguments, its output is
+// written to the log file. The last line of the output is returned as error
+// when the run fails. The run is killed when the context is done.
+func runGenerator(ctx context.Context, executable string, args []string, logFileName string) error {
+	logFile, err := os.Create(logFileName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create log file %s", logFileName)
+	}
+	defer logFile.Close()
+
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Run(); err != nil {
+		if lastLine := lastLogLine(logFileName); lastLine != "" {
+			return errors.New(lastLine)
+		}
+		return errors.Wrapf(err, "the generator failed, see %s", logFileName)
+	}
+	return nil
+}
+
+// lastLogLine returns the message of the last line of the log file written
+// by the log package, which is the one of log.Fatal and log.Panic. The lines
+// written by slog, but the errors, are skipped.
+func lastLogLine(logFileName string) string {
+	logFile, err := os.Open(logFileName)
+	if err != nil {
+		return ""
+	}
+	defer logFile.Close()
+
+	lastLine := ""
+	scanner := bufio.NewScanner(logFile)
+	scanner.Buffer(nil, 1024*1024) //nolint:mnd // the lines of the log can be long
+	for scanner.Scan() {
+		match := logLineRegexp.FindStringSubmatch(scanner.Text())
+		if match == nil || isSlogMessage(match[1]) {
+			continue
+		}
+		lastLine = match[1]
+	}
+	return lastLine
+}
+
+// isSlogMessage returns true when the message has been written by slog with
+// a level below the errors, like `INFO Generating into staging directory`.
+func isSlogMessage(message string) bool {
+	for _, level := range []string{"DEBUG ", "INFO ", "WARN "} {
+		if strings.HasPrefix(message, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func printRangeSummary(out io.Writer, outputDir string, results []rangeResult) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // padding of the table
+	fmt.Fprintln(writer, "VERSION\tSTATUS\tDURATION\tDETAILS")
+	for _, result := range results {
+		switch {
+		case result.stopped:
+			fmt.Fprintf(writer, "%s\tstopped\t%s\t%v\n", result.version, result.duration.Round(time.Second), result.err)
+		case result.err != nil:
+			fmt.Fprintf(writer, "%s\tfailed\t%s\t%v\n", result.version, result.duration.Round(time.Second), result.err)
+		default:
+			fmt.Fprintf(writer, "%s\tok\t%s\t%s\n", result.version, result.duration.Round(time.Second), filepath.Join(outputDir, result.version))
+		}
+	}
+
+	return writer.Flush()
+}
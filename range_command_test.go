@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinorVersionRange(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          string
+		to            string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "range",
+			from:     "1.14",
+			to:       "1.17",
+			expected: []string{"1.14", "1.15", "1.16", "1.17"},
+		},
+		{
+			name:     "single version",
+			from:     "1.33",
+			to:       "1.33",
+			expected: []string{"1.33"},
+		},
+		{
+			name:     "v prefix",
+			from:     "v1.32",
+			to:       "1.33",
+			expected: []string{"1.32", "1.33"},
+		},
+		{
+			name:          "cross-major range",
+			from:          "1.33",
+			to:            "2.0",
+			expectedError: "the range 1.33 - 2.0 spans different major versions",
+		},
+		{
+			name:          "reversed range",
+			from:          "1.33",
+			to:            "1.30",
+			expectedError: "the range 1.33 - 1.30 is empty",
+		},
+		{
+			name:          "patch version",
+			from:          "1.33.0",
+			to:            "1.34",
+			expectedError: "invalid Kubernetes minor version '1.33.0', it must be like 1.33",
+		},
+		{
+			name:          "bad version",
+			from:          "1.30",
+			to:            "latest",
+			expectedError: "invalid Kubernetes minor version 'latest', it must be like 1.33",
+		},
+		{
+			name:          "missing bound",
+			from:          "1.30",
+			expectedError: "both -from and -to must be specified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			versions, err := minorVersionRange(test.from, test.to)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, versions)
+		})
+	}
+}
+
+func TestCheckGeneratorArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{
+			name: "generator flags",
+			args: []string{"-layout", "module", "--repo=example.com/k8s-objects"},
+		},
+		{
+			name:          "kube-version",
+			args:          []string{"-layout", "module", "-kube-version", "1.33"},
+			expectedError: "the -kube-version flag cannot be passed to the runs of the generator, it's set by generate-range for each version",
+		},
+		{
+			name:          "output directory",
+			args:          []string{"--o=/tmp/objects"},
+			expectedError: "the -o flag cannot be passed to the runs of the generator, it's set by generate-range for each version",
+		},
+		{
+			name:          "lock file",
+			args:          []string{"-swagger-lock", "swagger.lock"},
+			expectedError: "the -swagger-lock flag cannot be passed to the runs of the generator, it's set by generate-range for each version",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkGeneratorArgs(test.args)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	assert.Equal(t, []string{"-kube-version", "1.33", "-o", "out/1.33", "-offline", "-j", "4"},
+		runArgs(&downloadFlags{}, "1.33", "out/1.33", 4))
+	assert.Equal(t, []string{"-kube-version", "1.33", "-o", "out/1.33", "-offline", "-j", "1", "-cache-dir", "cache", "-swagger-lock", "swagger.lock"},
+		runArgs(&downloadFlags{cacheDir: "cache", lockFile: "swagger.lock"}, "1.33", "out/1.33", 1))
+}
+
+func TestLastLogLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{
+			name: "log.Fatal after slog",
+			log: `2025/04/23 10:12:01 INFO Generating into staging directory path=/tmp/.1.33.staging
+2025/04/23 10:12:02 cannot read swagger file swagger.json
+2025/04/23 10:12:03 WARN File shadowed by overlay path=api/core/v1/pod.go
+`,
+			expected: "cannot read swagger file swagger.json",
+		},
+		{
+			name: "multi-line message",
+			log: `2025/04/23 10:12:01 Verifying the generated packages
+2025/04/23 10:12:05 go build failed on the generated packages:
+  - api/core/v1/pod.go:8:8: undefined: PodSpec
+`,
+			expected: "go build failed on the generated packages:",
+		},
+		{
+			name: "slog error",
+			log: `2025/04/23 10:12:01 INFO Generating into staging directory
+2025/04/23 10:12:02 ERROR Cannot restore the previous output path=/tmp/1.33
+panic: boom
+`,
+			expected: "ERROR Cannot restore the previous output path=/tmp/1.33",
+		},
+		{
+			name: "slog only",
+			log: `2025/04/23 10:12:01 INFO Generating into staging directory
+signal: killed
+`,
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logFileName := filepath.Join(t.TempDir(), "1.33.log")
+			require.NoError(t, os.WriteFile(logFileName, []byte(test.log), 0o600))
+			assert.Equal(t, test.expected, lastLogLine(logFileName))
+		})
+	}
+
+	assert.Empty(t, lastLogLine(filepath.Join(t.TempDir(), "missing.log")))
+}
+
+func TestPrintRangeSummary(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printRangeSummary(&out, "/out", []rangeResult{
+		{version: "1.31", duration: 2*time.Minute + 14*time.Second + 300*time.Millisecond},
+		{version: "1.32", duration: 35 * time.Second, err: errors.New("go build failed on the generated packages:")},
+		{version: "1.33", err: errors.New("stopped after the failure of 1.32"), stopped: true},
+	}))
+	assert.Equal(t, `VERSION  STATUS   DURATION  DETAILS
+1.31     ok       2m14s     /out/1.31
+1.32     failed   35s       go build failed on the generated packages:
+1.33     stopped  0s        stopped after the failure of 1.32
+`, out.String())
+}
+
+func TestRangeError(t *testing.T) {
+	require.NoError(t, rangeError([]rangeResult{{version: "1.32"}, {version: "1.33"}}))
+	require.EqualError(t, rangeError([]rangeResult{{version: "1.32"}, {version: "1.33", err: errors.New("failed")}}),
+		"1 of 2 Kubernetes versions could not be generated")
+}
+
+func TestRunGeneratorStopsWithTheContext(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+	logFileName := filepath.Join(t.TempDir(), "1.33.log")
+
+	require.NoError(t, runGenerator(context.Background(), shell, []string{"-c", "echo generated"}, logFileName))
+	data, err := os.ReadFile(logFileName)
+	require.NoError(t, err)
+	assert.Equal(t, "generated\n", string(data))
+
+	err = runGenerator(context.Background(), shell, []string{"-c", "echo '2025/04/23 10:12:02 cannot read swagger file'; exit 1"}, logFileName)
+	require.EqualError(t, err, "cannot read swagger file")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = runGenerator(ctx, shell, []string{"-c", "sleep 30"}, logFileName)
+	require.ErrorContains(t, err, "the generator failed, see "+logFileName)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestGenerateRangeStopsAfterAFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	// the fake generator fails for 1.31, and takes a while for the other
+	// versions
+	executable := filepath.Join(t.TempDir(), "generator")
+	require.NoError(t, os.WriteFile(executable, []byte(`#!/bin/sh
+if [ "$2" = "1.31" ]; then
+  echo "2025/04/23 10:12:02 cannot generate $2"
+  exit 1
+fi
+sleep 30
+`), 0o700)) //nolint:gosec // the script must be executable
+
+	options := &rangeFlags{outputDir: t.TempDir(), parallel: 2}
+	results := []rangeResult{{version: "1.31"}, {version: "1.32"}, {version: "1.33"}}
+	start := time.Now()
+	generateRange(context.Background(), executable, options, results)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	require.EqualError(t, results[0].err, "cannot generate 1.31")
+	assert.False(t, results[0].stopped)
+	for _, result := range results[1:] {
+		require.EqualError(t, result.err, "stopped after the failure of 1.31", result.version)
+		assert.True(t, result.stopped, result.version)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/kubewarden/k8s-objects-generator/split"
+)
+
+// selectionFlags holds the flags that select the definitions to generate.
+type selectionFlags struct {
+	include stringSliceFlag
+	exclude stringSliceFlag
+}
+
+func (s *selectionFlags) register(flags *flag.FlagSet) {
+	flags.Var(&s.include, "include",
+		"Generate only the definitions matched by this selector, plus the ones they reference: `package:<package>`, `gv:<group>/<version>` or `gvk:<group>/<version>/<kind>`. Can be repeated")
+	flags.Var(&s.exclude, "exclude", "Do not generate the definitions matched by this selector, unless they are referenced by other definitions. Can be repeated")
+}
+
+// apply prunes the plan down to the selected definitions.
+func (s *selectionFlags) apply(plan *split.RefactoringPlan) error {
+	if len(s.include) == 0 && len(s.exclude) == 0 {
+		return nil
+	}
+
+	include, err := parseSelectors(s.include)
+	if err != nil {
+		return err
+	}
+	exclude, err := parseSelectors(s.exclude)
+	if err != nil {
+		return err
+	}
+
+	return plan.Select(include, exclude)
+}
+
+func parseSelectors(values []string) ([]split.Selector, error) {
+	selectors := make([]split.Selector, 0, len(values))
+	for _, value := range values {
+		selector, err := split.ParseSelector(value)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
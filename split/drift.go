@@ -0,0 +1,174 @@
+package split
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+)
+
+// FileDiff is a file that differs between two output trees.
+type FileDiff struct {
+	// Path of the file, relative to the root of the trees
+	Path string
+	// Diff is the unified diff from the existing file to the generated one
+	Diff string
+}
+
+// DiffTrees compares the files of an existing output tree with the ones of a
+// generated tree, the differences are sorted by path. The `.git` directories
+// are ignored, the existing tree can be a checkout of the module. The version
+// of the generator recorded by the metadata files is ignored too, it changes
+// with the build of the generator while the generated files don't, and so are
+// the sources of the inputs, which depend on how the generator has been run.
+func DiffTrees(afs afero.Fs, existing, generated string) ([]FileDiff, error) {
+	existingFiles, err := treeFiles(afs, existing)
+	if err != nil {
+		return nil, err
+	}
+	generatedFiles, err := treeFiles(afs, generated)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := append(slices.Clone(existingFiles), generatedFiles...)
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	diffs := []FileDiff{}
+	for _, path := range paths {
+		unifiedDiff := difflib.UnifiedDiff{
+			FromFile: "/dev/null",
+			ToFile:   "/dev/null",
+			Context:  3, //nolint:mnd // the default context of diff -u
+		}
+		existingData, existingFound, err := readTreeFile(afs, existing, path)
+		if err != nil {
+			return nil, err
+		}
+		if existingFound {
+			unifiedDiff.FromFile = filepath.ToSlash(filepath.Join("a", path))
+			unifiedDiff.A = splitLines(existingData)
+		}
+		generatedData, generatedFound, err := readTreeFile(afs, generated, path)
+		if err != nil {
+			return nil, err
+		}
+		if generatedFound {
+			unifiedDiff.ToFile = filepath.ToSlash(filepath.Join("b", path))
+			unifiedDiff.B = splitLines(generatedData)
+		}
+
+		if existingFound == generatedFound && sameTreeFile(path, existingData, generatedData) {
+			continue
+		}
+		diff, err := difflib.GetUnifiedDiffString(unifiedDiff)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot compute the diff of %s", path)
+		}
+		diffs = append(diffs, FileDiff{Path: path, Diff: diff})
+	}
+
+	return diffs, nil
+}
+
+// sameTreeFile returns true when the existing file and the generated one have
+// the same content. The metadata files are compared without the version of
+// the generator.
+func sameTreeFile(path string, existingData, generatedData []byte) bool {
+	if string(existingData) == string(generatedData) {
+		return true
+	}
+	if path != MetadataFileName {
+		return false
+	}
+
+	existingMetadata, existingErr := comparableMetadata(existingData)
+	generatedMetadata, generatedErr := comparableMetadata(generatedData)
+	return existingErr == nil && generatedErr == nil && reflect.DeepEqual(existingMetadata, generatedMetadata)
+}
+
+// comparableMetadata decodes the metadata file, dropping the version of the
+// generator and the sources of the inputs, which are compared by digest.
+func comparableMetadata(data []byte) (*Metadata, error) {
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	if metadata.Provenance != nil {
+		metadata.GeneratorVersion = ""
+		for i := range metadata.Inputs {
+			metadata.Inputs[i].Source = ""
+		}
+	}
+	return &metadata, nil
+}
+
+// splitLines splits the content of a file into lines, each ending with a
+// newline, including the last one.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// treeFiles returns the paths of the regular files of the tree, relative to
+// its root. A missing tree has no files.
+func treeFiles(afs afero.Fs, root string) ([]string, error) {
+	exists, err := afero.DirExists(afs, root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot access %s", root)
+	}
+	if !exists {
+		return []string{}, nil
+	}
+
+	files := []string{}
+	err = afero.Walk(afs, root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", root)
+	}
+	return files, nil
+}
+
+func readTreeFile(afs afero.Fs, root, path string) ([]byte, bool, error) {
+	fileName := filepath.Join(root, path)
+	exists, err := afero.Exists(afs, fileName)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "cannot access %s", fileName)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+	data, err := afero.ReadFile(afs, fileName)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "cannot read %s", fileName)
+	}
+	return data, true, nil
+}
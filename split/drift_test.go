@@ -0,0 +1,97 @@
+package split
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTrees(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	write := func(path, content string) {
+		require.NoError(t, afero.WriteFile(afs, path, []byte(content), 0o600))
+	}
+	write("/existing/.git/HEAD", "ref: refs/heads/main\n")
+	write("/existing/KUBERNETES_VERSION", "1.33.0")
+	write("/existing/api/core/v1/pod.go", "package v1\n\ntype Pod struct {\n\tName string\n}\n")
+	write("/existing/api/core/v1/stale.go", "package v1\n")
+	write("/generated/KUBERNETES_VERSION", "1.33.0")
+	write("/generated/api/core/v1/pod.go", "package v1\n\ntype Pod struct {\n\tNamespace string\n}\n")
+	write("/generated/api/core/v1/node.go", "package v1\n")
+
+	diffs, err := DiffTrees(afs, "/existing", "/generated")
+	require.NoError(t, err)
+	assert.Equal(t, []FileDiff{
+		{
+			Path: "api/core/v1/node.go",
+			Diff: "--- /dev/null\n+++ b/api/core/v1/node.go\n@@ -0,0 +1 @@\n+package v1\n",
+		},
+		{
+			Path: "api/core/v1/pod.go",
+			Diff: "--- a/api/core/v1/pod.go\n+++ b/api/core/v1/pod.go\n@@ -1,5 +1,5 @@\n package v1\n \n type Pod struct {\n-\tName string\n+\tNamespace string\n }\n",
+		},
+		{
+			Path: "api/core/v1/stale.go",
+			Diff: "--- a/api/core/v1/stale.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-package v1\n",
+		},
+	}, diffs)
+
+	// a missing tree has no files
+	diffs, err = DiffTrees(afs, "/missing", "/generated")
+	require.NoError(t, err)
+	assert.Len(t, diffs, 3)
+
+	diffs, err = DiffTrees(afs, "/generated", "/generated")
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+}
+
+func TestDiffTreesIgnoresTheEnvironment(t *testing.T) {
+	generate := func(provenance Provenance) string {
+		project, err := NewProject(t.TempDir(), "example.com/objects", LayoutModule)
+		require.NoError(t, err)
+		require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+		require.NoError(t, os.WriteFile(filepath.Join(project.Root, "pod.go"), []byte("package objects\n"), 0o600))
+		require.NoError(t, project.WriteManifest(provenance))
+		require.NoError(t, project.Commit())
+		return project.Target
+	}
+	provenance := Provenance{
+		GeneratorVersion: "v1.0.0",
+		EmitterVersion:   "2",
+		Inputs:           []ManifestInput{{Source: "swagger.json", SHA256: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"}},
+		Configuration:    []byte(`{"modulePath":"example.com/objects"}`),
+	}
+	existing := generate(provenance)
+
+	// the same inputs regenerated by a local build of the generator
+	provenance.GeneratorVersion = "sha256:8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4"
+	diffs, err := DiffTrees(afero.NewOsFs(), existing, generate(provenance))
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	// the inputs are compared by digest
+	provenance.Inputs = []ManifestInput{{Source: "../specs/swagger.json", SHA256: provenance.Inputs[0].SHA256}}
+	diffs, err = DiffTrees(afero.NewOsFs(), existing, generate(provenance))
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	provenance.Inputs = []ManifestInput{{Source: "swagger.json", SHA256: "7ed12dba3cd6fd97a382d6b0aa71c12bc972a06515a80bf8e98b7c0b5720a639"}}
+	diffs, err = DiffTrees(afero.NewOsFs(), existing, generate(provenance))
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, MetadataFileName, diffs[0].Path)
+
+	// the other fields of the provenance are compared
+	provenance.Inputs = []ManifestInput{{Source: "swagger.json", SHA256: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"}}
+	provenance.EmitterVersion = "3"
+	diffs, err = DiffTrees(afero.NewOsFs(), existing, generate(provenance))
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, MetadataFileName, diffs[0].Path)
+	assert.Contains(t, diffs[0].Diff, `+  "emitterVersion": "3",`)
+}
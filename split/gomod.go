@@ -0,0 +1,130 @@
+package split
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// DefaultGoVersion is the version of the `go` directive of the generated
+// go.mod file.
+const DefaultGoVersion = "1.20"
+
+// GoModule defines the content of the go.mod file of a generated module.
+type GoModule struct {
+	// Go is the version of the `go` directive
+	Go string `json:"go" yaml:"go"`
+	// Toolchain is the version of the `toolchain` directive, like `go1.24.2`,
+	// omitted when empty
+	Toolchain string `json:"toolchain,omitempty" yaml:"toolchain"`
+	// Require are modules required on top of the ones added by
+	// `go mod tidy`
+	Require []ModuleVersion `json:"require,omitempty" yaml:"require"`
+	Replace []ModuleReplace `json:"replace,omitempty" yaml:"replace"`
+}
+
+// ModuleVersion is a module path, with an optional version.
+type ModuleVersion struct {
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version,omitempty" yaml:"version"`
+}
+
+func (m ModuleVersion) String() string {
+	if m.Version == "" {
+		return m.Path
+	}
+	return m.Path + " " + m.Version
+}
+
+// ModuleReplace is a `replace` directive.
+type ModuleReplace struct {
+	Old ModuleVersion `json:"old" yaml:"old"`
+	New ModuleVersion `json:"new" yaml:"new"`
+}
+
+func (r ModuleReplace) String() string {
+	return r.Old.String() + " => " + r.New.String()
+}
+
+// DefaultGoModule returns the go.mod settings used when none is configured.
+// The fork of strfmt is compatible with TinyGo.
+func DefaultGoModule() GoModule {
+	return GoModule{
+		Go: DefaultGoVersion,
+		Replace: []ModuleReplace{
+			{
+				Old: ModuleVersion{Path: "github.com/go-openapi/strfmt"},
+				New: ModuleVersion{Path: "github.com/kubewarden/strfmt", Version: "v0.1.3"},
+			},
+		},
+	}
+}
+
+const GO_MOD_TEMPLATE = `
+module {{ .Repository }}
+
+go {{ .Go }}
+{{- with .Toolchain }}
+
+toolchain {{ . }}
+{{- end }}
+{{- with .Require }}
+
+require (
+{{- range . }}
+	{{ . }}
+{{- end }}
+)
+{{- end }}
+{{- range .Replace }}
+
+replace {{ . }}
+{{- end }}
+`
+
+// GoModFile returns the go.mod file of the module. The file is parsed before
+// being returned, invalid versions and directives are reported as errors.
+func (m *GoModule) GoModFile(modulePath string) ([]byte, error) {
+	templateData := struct {
+		Repository string
+		GoModule
+	}{
+		Repository: modulePath,
+		GoModule:   *m,
+	}
+
+	goModTemplate, err := template.New("go.mod").Parse(GO_MOD_TEMPLATE)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := goModTemplate.Execute(&buf, templateData); err != nil {
+		return nil, err
+	}
+
+	if _, err := modfile.Parse("go.mod", buf.Bytes(), nil); err != nil {
+		return nil, goModError(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// goModError strips the position from the errors of the go.mod parser: the
+// lines of the rendered file don't mean anything to the user.
+func goModError(err error) error {
+	var errorList modfile.ErrorList
+	if !errors.As(err, &errorList) {
+		return err
+	}
+
+	messages := make([]string, 0, len(errorList))
+	for _, e := range errorList {
+		e.Filename = ""
+		e.Pos = modfile.Position{}
+		messages = append(messages, e.Error())
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
@@ -0,0 +1,58 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultGoModFile(t *testing.T) {
+	goModule := DefaultGoModule()
+	goMod, err := goModule.GoModFile("github.com/kubewarden/k8s-objects")
+	require.NoError(t, err)
+	assert.Equal(t, `
+module github.com/kubewarden/k8s-objects
+
+go 1.20
+
+replace github.com/go-openapi/strfmt => github.com/kubewarden/strfmt v0.1.3
+`, string(goMod))
+}
+
+func TestGoModFile(t *testing.T) {
+	goModule := GoModule{
+		Go:        "1.24",
+		Toolchain: "go1.24.2",
+		Require: []ModuleVersion{
+			{Path: "github.com/go-openapi/strfmt", Version: "v0.23.0"},
+			{Path: "github.com/go-openapi/swag", Version: "v0.23.0"},
+		},
+		Replace: []ModuleReplace{
+			{
+				Old: ModuleVersion{Path: "github.com/go-openapi/strfmt", Version: "v0.23.0"},
+				New: ModuleVersion{Path: "../strfmt"},
+			},
+		},
+	}
+	goMod, err := goModule.GoModFile("example.com/objects")
+	require.NoError(t, err)
+	assert.Equal(t, `
+module example.com/objects
+
+go 1.24
+
+toolchain go1.24.2
+
+require (
+	github.com/go-openapi/strfmt v0.23.0
+	github.com/go-openapi/swag v0.23.0
+)
+
+replace github.com/go-openapi/strfmt v0.23.0 => ../strfmt
+`, string(goMod))
+
+	goModule = GoModule{Go: "1.24", Toolchain: "1.24.2"}
+	_, err = goModule.GoModFile("example.com/objects")
+	require.EqualError(t, err, "invalid toolchain version '1.24.2': must match format go1.23.0 or default")
+}
@@ -34,6 +34,13 @@ func (g groupVersionResource) String() string {
 	return fmt.Sprintf("%s/%s,Resource=%s", g.Group, g.Version, g.Kind)
 }
 
+// resourceTemplateData is the data of the GVK templates.
+type resourceTemplateData struct {
+	groupVersionResource
+	// GitRepo is the module path of the generated packages
+	GitRepo string
+}
+
 type groupResource struct {
 	fs afero.Fs
 }
@@ -56,14 +63,19 @@ func (g *groupResource) Generate(project Project, plan *RefactoringPlan) error {
 
 	var lastGVK *groupVersionResource
 	var gvkCount int
-	for _, pkg := range plan.Packages {
+	for _, pkgName := range sortedKeys(plan.Packages) {
+		pkg := plan.Packages[pkgName]
 		slog.Info("============================================================================")
 		slog.Info("Generating GVK files for module", "module", pkg.Name)
 		for _, dfn := range pkg.Definitions {
+			if _, overridden := plan.TypeOverrides.Lookup(dfn.ID); overridden {
+				// methods cannot be added to the types of other packages
+				continue
+			}
 			if gvk := groupKindResource(dfn); gvk != nil {
 				gvkCount++
 				objectKindFilePath := filepath.Join(project.Root, dfn.PackageName, fmt.Sprintf("%s_gvk.go", strcase.ToSnake(gvk.Kind)))
-				if err = g.generateResourceFile(objectKindFilePath, objectKindTemplate, gvk); err != nil {
+				if err = g.generateResourceFile(objectKindFilePath, objectKindTemplate, project.GitRepo, gvk); err != nil {
 					return err
 				}
 				lastGVK = gvk
@@ -72,7 +84,7 @@ func (g *groupResource) Generate(project Project, plan *RefactoringPlan) error {
 		if lastGVK != nil {
 			// Generates group_info.go file (one per GroupVersion combination)
 			groupInfoFilePath := filepath.Join(project.Root, pkg.Name, "group_info.go")
-			if err = g.generateResourceFile(groupInfoFilePath, groupInfoTemplate, lastGVK); err != nil {
+			if err = g.generateResourceFile(groupInfoFilePath, groupInfoTemplate, project.GitRepo, lastGVK); err != nil {
 				return err
 			}
 
@@ -82,10 +94,10 @@ func (g *groupResource) Generate(project Project, plan *RefactoringPlan) error {
 		}
 	}
 
-	return g.copyStaticFiles(project.Root)
+	return g.copyStaticFiles(project.Root, project.GitRepo)
 }
 
-func (g *groupResource) generateResourceFile(path string, templ *template.Template, gvk *groupVersionResource) error {
+func (g *groupResource) generateResourceFile(path string, templ *template.Template, gitRepo string, gvk *groupVersionResource) error {
 	gvkFile, err := g.fs.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600) //nolint:mnd // mnd doesn't support file octals yet
 	if err != nil {
 		return err
@@ -97,7 +109,7 @@ func (g *groupResource) generateResourceFile(path string, templ *template.Templa
 		}
 	}()
 
-	if err := templ.Execute(gvkFile, gvk); err != nil {
+	if err := templ.Execute(gvkFile, resourceTemplateData{groupVersionResource: *gvk, GitRepo: gitRepo}); err != nil {
 		return fmt.Errorf("failed to process template for %s: %w", gvk.String(), err)
 	}
 
@@ -105,25 +117,37 @@ func (g *groupResource) generateResourceFile(path string, templ *template.Templa
 }
 
 func groupKindResource(definition *swaggerhelpers.Definition) *groupVersionResource {
+	gvk, unsupported := definitionGroupKindResource(definition)
+	if unsupported {
+		slog.Info("GVK specific key format for package definition is not found. Skipping...", "GVK", kubernetesGroupVersionKindKey, "package", definition.PackageName)
+	}
+	return gvk
+}
+
+// definitionGroupKindResource returns the kind declared by the definition, if
+// any, without logging. The second value is true when the kind is declared
+// using an unsupported format, like multiple kinds.
+func definitionGroupKindResource(definition *swaggerhelpers.Definition) (*groupVersionResource, bool) {
 	extension := definition.SwaggerDefinition.Extensions
 	if extension == nil || extension[kubernetesGroupVersionKindKey] == nil {
-		return nil
+		return nil, false
 	}
 
 	kubeExtension, isKubeExtension := asKubernetesExtension(extension)
 	if !isKubeExtension {
-		slog.Info("GVK specific key format for package definition is not found. Skipping...", "GVK", kubernetesGroupVersionKindKey, "package", definition.PackageName)
-		return nil
+		return nil, true
 	}
 
 	return &groupVersionResource{
 		Group:   kubeExtension[kubernetesGroupKey],
 		Version: kubeExtension[kubernetesVersionKey],
 		Kind:    kubeExtension[kubernetesKindKey],
-	}
+	}, false
 }
 
-func (g *groupResource) copyStaticFiles(targetRoot string) error {
+// copyStaticFiles copies the embedded static files, their imports of the
+// static module are rewritten to import the generated module.
+func (g *groupResource) copyStaticFiles(targetRoot, gitRepo string) error {
 	slog.Info("============================================================================")
 	slog.Info("Generating static content files")
 	err := fs.WalkDir(object_templates.ApimachineryRoot, ".", func(path string, d fs.DirEntry, err error) error {
@@ -139,6 +163,11 @@ func (g *groupResource) copyStaticFiles(targetRoot string) error {
 		if err != nil {
 			return err
 		}
+		if filepath.Ext(path) == ".go" {
+			if sourceBuf, err = rewriteModuleImports(path, sourceBuf, object_templates.StaticModulePath, gitRepo); err != nil {
+				return err
+			}
+		}
 
 		if err = g.fs.MkdirAll(filepath.Join(targetRoot, filepath.Dir(path)), os.ModePerm); err != nil {
 			return nil
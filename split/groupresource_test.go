@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
 )
 
 //go:embed testdata/event_gvk.go.gold
@@ -17,6 +19,12 @@ var eventGvkGold string
 //go:embed testdata/group_info.go.gold
 var groupInfoGold string
 
+//go:embed testdata/event_gvk_fork.go.gold
+var eventGvkForkGold string
+
+//go:embed testdata/group_info_fork.go.gold
+var groupInfoForkGold string
+
 func TestKubernetesExtensionParse(t *testing.T) {
 	tests := []struct {
 		extensionJSON   string
@@ -69,25 +77,51 @@ func TestKubernetesExtensionParse(t *testing.T) {
 }
 
 func TestGenerateGroupResources(t *testing.T) {
-	outputDir := "/testout"
-	project, err := NewProject(outputDir, "", "")
-	require.NoError(t, err)
+	tests := []struct {
+		name          string
+		gitRepo       string
+		eventGvkGold  string
+		groupInfoGold string
+	}{
+		{
+			name:          "default repository",
+			gitRepo:       "github.com/kubewarden/k8s-objects",
+			eventGvkGold:  eventGvkGold,
+			groupInfoGold: groupInfoGold,
+		},
+		{
+			name:          "fork",
+			gitRepo:       "example.com/fork/k8s-objects",
+			eventGvkGold:  eventGvkForkGold,
+			groupInfoGold: groupInfoForkGold,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outputDir := "/testout"
+			project, err := NewProject(outputDir, test.gitRepo, LayoutGOPATH)
+			require.NoError(t, err)
 
-	splitter, err := NewSplitter(filepath.Join("testdata", "test-swagger.json"))
-	require.NoError(t, err)
+			splitter, err := NewSplitter(filepath.Join("testdata", "test-swagger.json"))
+			require.NoError(t, err)
 
-	refactoringPlan, err := splitter.ComputeRefactoringPlan()
-	require.NoError(t, err)
+			refactoringPlan, err := splitter.ComputeRefactoringPlan(swaggerhelpers.DefaultPackageMapping(), nil)
+			require.NoError(t, err)
 
-	fs := afero.NewMemMapFs()
-	groupResource := NewGroupResource(fs)
-	require.NoError(t, groupResource.Generate(project, refactoringPlan))
+			fs := afero.NewMemMapFs()
+			groupResource := NewGroupResource(fs)
+			require.NoError(t, groupResource.Generate(project, refactoringPlan))
 
-	eventGvk, err := afero.ReadFile(fs, filepath.Join(outputDir, "src/api/events/v1/event_gvk.go"))
-	require.NoError(t, err)
-	groupInfo, err := afero.ReadFile(fs, filepath.Join(outputDir, "src/api/events/v1/group_info.go"))
-	require.NoError(t, err)
+			eventGvk, err := afero.ReadFile(fs, filepath.Join(project.Root, "api/events/v1/event_gvk.go"))
+			require.NoError(t, err)
+			groupInfo, err := afero.ReadFile(fs, filepath.Join(project.Root, "api/events/v1/group_info.go"))
+			require.NoError(t, err)
 
-	assert.Equal(t, eventGvkGold, string(eventGvk))
-	assert.Equal(t, groupInfoGold, string(groupInfo))
+			// the generated files import the packages of the configured repository
+			assert.Equal(t, test.eventGvkGold, string(eventGvk))
+			assert.Equal(t, test.groupInfoGold, string(groupInfo))
+			require.NoError(t, CheckModuleImports(fs, project.Root, project.GitRepo, ImportRules{}))
+		})
+	}
 }
@@ -0,0 +1,125 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// WriteManifest completes the metadata file of the project with the
+// provenance of the packages and the checksums of the generated files. It
+// must be called once all the files have been generated.
+func (p *Project) WriteManifest(provenance Provenance) error {
+	afs := afero.NewOsFs()
+	metadata, err := ReadMetadata(afs, p.Root)
+	if err != nil {
+		return err
+	}
+
+	metadata.Provenance = &provenance
+	if metadata.Files, err = ChecksumFiles(afs, p.Root); err != nil {
+		return err
+	}
+	return writeMetadata(p.Root, *metadata)
+}
+
+// ChecksumFiles returns the SHA-256 digests of the files of the tree, indexed
+// by their slash separated path relative to the root. The metadata file and
+// the `.git` directories are skipped.
+func ChecksumFiles(afs afero.Fs, root string) (map[string]string, error) {
+	files, err := treeFiles(afs, root)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]string, len(files))
+	for _, file := range files {
+		if file == MetadataFileName {
+			continue
+		}
+		data, err := afero.ReadFile(afs, filepath.Join(root, file))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read %s", filepath.Join(root, file))
+		}
+		digest := sha256.Sum256(data)
+		checksums[filepath.ToSlash(file)] = hex.EncodeToString(digest[:])
+	}
+	return checksums, nil
+}
+
+// ManifestMismatch lists the files of a project that don't match its
+// manifest, their paths are sorted.
+type ManifestMismatch struct {
+	// Modified are the files whose content changed
+	Modified []string
+	// Missing are the files recorded by the manifest that have been removed
+	Missing []string
+	// Added are the files not recorded by the manifest
+	Added []string
+}
+
+// Empty is true when all the files match the manifest.
+func (m *ManifestMismatch) Empty() bool {
+	return len(m.Modified) == 0 && len(m.Missing) == 0 && len(m.Added) == 0
+}
+
+func (m *ManifestMismatch) String() string {
+	lines := []string{}
+	for _, group := range []struct {
+		status string
+		paths  []string
+	}{
+		{"modified", m.Modified},
+		{"missing", m.Missing},
+		{"added", m.Added},
+	} {
+		for _, path := range group.paths {
+			lines = append(lines, fmt.Sprintf("%s: %s", group.status, path))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// VerifyManifest compares the files of the project rooted at the directory
+// with the checksums recorded by its manifest.
+func VerifyManifest(afs afero.Fs, root string) (*ManifestMismatch, error) {
+	metadata, err := ReadMetadata(afs, root)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Files == nil {
+		return nil, fmt.Errorf("the metadata file of %s has no checksums, the files have been generated by an older version of k8s-objects-generator", root)
+	}
+
+	checksums, err := ChecksumFiles(afs, root)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatch := &ManifestMismatch{Modified: []string{}, Missing: []string{}, Added: []string{}}
+	for path, checksum := range metadata.Files {
+		current, found := checksums[path]
+		switch {
+		case !found:
+			mismatch.Missing = append(mismatch.Missing, path)
+		case current != checksum:
+			mismatch.Modified = append(mismatch.Modified, path)
+		}
+	}
+	for path := range checksums {
+		if _, found := metadata.Files[path]; !found {
+			mismatch.Added = append(mismatch.Added, path)
+		}
+	}
+
+	slices.Sort(mismatch.Modified)
+	slices.Sort(mismatch.Missing)
+	slices.Sort(mismatch.Added)
+	return mismatch, nil
+}
@@ -0,0 +1,92 @@
+package split
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteManifest(t *testing.T) {
+	project, err := NewProject(t.TempDir(), "example.com/objects", LayoutModule)
+	require.NoError(t, err)
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+	require.NoError(t, project.WriteManifest(Provenance{
+		GeneratorVersion: "v1.0.0",
+		EmitterVersion:   "1",
+		Inputs:           []ManifestInput{{Source: "swagger.json", SHA256: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"}},
+		Configuration:    []byte(`{"modulePath":"example.com/objects"}`),
+	}))
+	require.NoError(t, project.Commit())
+
+	metadata, err := ReadMetadata(afero.NewOsFs(), project.Root)
+	require.NoError(t, err)
+	assert.Equal(t, "1.33.0", metadata.KubernetesVersion)
+	assert.Equal(t, "v1.0.0", metadata.GeneratorVersion)
+	assert.JSONEq(t, `{"modulePath":"example.com/objects"}`, string(metadata.Configuration))
+	assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", metadata.Files["swagger.json"])
+	assert.Contains(t, metadata.Files, "go.mod")
+	assert.NotContains(t, metadata.Files, MetadataFileName)
+
+	mismatch, err := VerifyManifest(afero.NewOsFs(), project.Root)
+	require.NoError(t, err)
+	assert.True(t, mismatch.Empty())
+
+	// the metadata of an older generator has no checksums
+	require.NoError(t, writeMetadata(project.Root, Metadata{KubernetesVersion: "1.33.0"}))
+	_, err = VerifyManifest(afero.NewOsFs(), project.Root)
+	require.ErrorContains(t, err, "has no checksums")
+}
+
+func TestChecksumFiles(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	write := func(path, content string) {
+		require.NoError(t, afero.WriteFile(afs, path, []byte(content), 0o600))
+	}
+	write("/project/KUBERNETES_VERSION", "1.33.0")
+	write("/project/api/core/v1/pod.go", "package v1\n")
+	write("/project/api/core/v1/node.go", "package v1\n")
+	write("/project/metadata.json", "{}")
+	write("/project/.git/HEAD", "ref: refs/heads/main\n")
+	checksums, err := ChecksumFiles(afs, "/project")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"KUBERNETES_VERSION":  "c503027862872879fc112933d2f62c64e04557f4689213a90e5d85728762b7d8",
+		"api/core/v1/node.go": "7ed12dba3cd6fd97a382d6b0aa71c12bc972a06515a80bf8e98b7c0b5720a639",
+		"api/core/v1/pod.go":  "7ed12dba3cd6fd97a382d6b0aa71c12bc972a06515a80bf8e98b7c0b5720a639",
+	}, checksums)
+}
+
+func TestVerifyManifestReportsHandEdits(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		fileName := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0o750))
+		require.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+	}
+	write("KUBERNETES_VERSION", "1.33.0")
+	write("api/core/v1/pod.go", "package v1\n")
+	write("api/core/v1/node.go", "package v1\n")
+	write(".git/HEAD", "ref: refs/heads/main\n")
+	require.NoError(t, writeMetadata(root, Metadata{KubernetesVersion: "1.33.0"}))
+	project := Project{Root: root}
+	require.NoError(t, project.WriteManifest(Provenance{}))
+
+	write("api/core/v1/pod.go", "package v1\n\n// edited by hand\n")
+	require.NoError(t, os.Remove(filepath.Join(root, "api", "core", "v1", "node.go")))
+	write("api/core/v1/extra.go", "package v1\n")
+	write(".git/ORIG_HEAD", "0000000000000000000000000000000000000000\n")
+
+	mismatch, err := VerifyManifest(afero.NewOsFs(), root)
+	require.NoError(t, err)
+	assert.Equal(t, &ManifestMismatch{
+		Modified: []string{"api/core/v1/pod.go"},
+		Missing:  []string{"api/core/v1/node.go"},
+		Added:    []string{"api/core/v1/extra.go"},
+	}, mismatch)
+	assert.False(t, mismatch.Empty())
+	assert.Equal(t, "modified: api/core/v1/pod.go\nmissing: api/core/v1/node.go\nadded: api/core/v1/extra.go", mismatch.String())
+}
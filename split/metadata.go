@@ -0,0 +1,75 @@
+package split
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// MetadataFileName is the file, inside of the root of the project, that
+// describes how the packages have been generated.
+const MetadataFileName = "metadata.json"
+
+// Metadata describes how the packages have been generated. Once the
+// generation is complete it's the manifest of the project: it records the
+// provenance of the packages and the checksums of the generated files.
+type Metadata struct {
+	KubernetesVersion string `json:"kubernetesVersion"`
+	// GoModule holds the settings of the generated go.mod file, it's nil
+	// when generating inside of an existing module
+	GoModule *GoModule `json:"goModule,omitempty"`
+	// Provenance is nil until the manifest is written
+	*Provenance
+	// Files are the SHA-256 digests of the generated files, indexed by their
+	// slash separated path relative to the root of the project. The metadata
+	// file itself is not part of them
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// Provenance describes what the packages have been generated from, and how.
+type Provenance struct {
+	GeneratorVersion string `json:"generatorVersion"`
+	// EmitterVersion is the version of the code emitted for the models
+	EmitterVersion string          `json:"emitterVersion"`
+	Inputs         []ManifestInput `json:"inputs"`
+	// Configuration is the effective configuration of the generator
+	Configuration json.RawMessage `json:"configuration,omitempty"`
+}
+
+// ManifestInput is an OpenAPI document the packages have been generated from.
+type ManifestInput struct {
+	Source string `json:"source"`
+	SHA256 string `json:"sha256"`
+}
+
+// ReadMetadata reads the metadata file of the project rooted at the
+// directory.
+func ReadMetadata(afs afero.Fs, root string) (*Metadata, error) {
+	metadataFile := filepath.Join(root, MetadataFileName)
+	data, err := afero.ReadFile(afs, metadataFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read metadata file %s", metadataFile)
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse metadata file %s", metadataFile)
+	}
+	return &metadata, nil
+}
+
+func writeMetadata(root string, metadata Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot encode the metadata")
+	}
+
+	metadataFile := filepath.Join(root, MetadataFileName)
+	if err := os.WriteFile(metadataFile, append(data, '\n'), 0o600); err != nil {
+		return errors.Wrapf(err, "cannot write metadata file %s", metadataFile)
+	}
+	return nil
+}
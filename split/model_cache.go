@@ -0,0 +1,153 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// ModelCache is an on-disk cache of the files generated for each package.
+//
+// The cache is content-addressed: the files of a package are stored by the
+// SHA-256 digest of the package name, of its rendered swagger, of the
+// fingerprint of the emitter and of the version of the generator. Packages that didn't
+// change between two runs are restored from the cache instead of being
+// generated again:
+//
+//	<root>/sha256/<key>/<file>
+type ModelCache struct {
+	root             string
+	generatorVersion string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// ModelCacheStats reports how many packages have been restored from the cache,
+// and how many had to be generated.
+type ModelCacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// NewModelCache returns a cache stored inside of the `root` directory. The
+// `generatorVersion` must change whenever the generator produces different
+// files for the same swagger.
+func NewModelCache(root, generatorVersion string) (*ModelCache, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot calculate absolute path of %s", root)
+	}
+
+	return &ModelCache{
+		root:             absRoot,
+		generatorVersion: generatorVersion,
+	}, nil
+}
+
+// Key returns the key of the package, given its rendered swagger and the
+// fingerprint of the emitter generating it.
+func (c *ModelCache) Key(emitterFingerprint, pkgName string, swaggerData []byte) string {
+	hash := sha256.New()
+	// every field is terminated by a NUL byte, which cannot be part of
+	// any of them, to make the key unambiguous
+	for _, field := range []string{c.generatorVersion, emitterFingerprint, pkgName} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	hash.Write(swaggerData)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Restore copies the cached files of the key inside of `targetDir`. It
+// returns false when the cache doesn't have the key.
+func (c *ModelCache) Restore(key, targetDir string) (bool, error) {
+	entries, err := os.ReadDir(c.entryDir(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			c.misses.Add(1)
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "cannot read cache entry %s", key)
+	}
+
+	if err := copyFiles(c.entryDir(key), targetDir, entries); err != nil {
+		return false, errors.Wrapf(err, "cannot restore cache entry %s", key)
+	}
+
+	c.hits.Add(1)
+	return true, nil
+}
+
+// Store adds the files of `sourceDir` to the cache. Only the regular files
+// are cached, the directories of the nested packages are ignored.
+func (c *ModelCache) Store(key, sourceDir string) error {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read directory %s", sourceDir)
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.root, "sha256"), 0o750); err != nil { //nolint:mnd // mnd doesn't support file octals yet
+		return errors.Wrap(err, "cannot create cache directory")
+	}
+
+	// the files are written to a temporary directory that is then renamed,
+	// concurrent readers never see a partial entry
+	tmpDir, err := os.MkdirTemp(filepath.Join(c.root, "sha256"), "."+key+".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "cannot create cache directory")
+	}
+	defer os.RemoveAll(tmpDir) //nolint:errcheck // the directory doesn't exist anymore once renamed
+
+	if err := copyFiles(sourceDir, tmpDir, entries); err != nil {
+		return errors.Wrapf(err, "cannot store cache entry %s", key)
+	}
+
+	if err := os.Rename(tmpDir, c.entryDir(key)); err != nil {
+		if _, statErr := os.Stat(c.entryDir(key)); statErr == nil {
+			// stored by another run in the meantime
+			return nil
+		}
+		return errors.Wrapf(err, "cannot store cache entry %s", key)
+	}
+
+	return nil
+}
+
+// Stats returns the hits and the misses of the cache.
+func (c *ModelCache) Stats() ModelCacheStats {
+	return ModelCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+func (c *ModelCache) entryDir(key string) string {
+	return filepath.Join(c.root, "sha256", key)
+}
+
+// copyFiles copies the regular files among the entries of `sourceDir` to
+// `targetDir`.
+func copyFiles(sourceDir, targetDir string, entries []fs.DirEntry) error {
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(sourceDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(targetDir, entry.Name()), data, 0o600); err != nil { //nolint:mnd // mnd doesn't support file octals yet
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package split
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelCache(t *testing.T) {
+	cache, err := NewModelCache(t.TempDir(), "v1.0.0")
+	require.NoError(t, err)
+
+	swaggerData := []byte(`{"swagger": "2.0"}`)
+	key := cache.Key("fingerprint", "api/core/v1", swaggerData)
+
+	targetDir := t.TempDir()
+	restored, err := cache.Restore(key, targetDir)
+	require.NoError(t, err)
+	assert.False(t, restored)
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "swagger.json"), swaggerData, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "pod.go"), []byte("package v1\n"), 0o600))
+	// nested packages are not part of the entry
+	require.NoError(t, os.Mkdir(filepath.Join(sourceDir, "nested"), 0o750))
+	require.NoError(t, cache.Store(key, sourceDir))
+	// storing the same entry twice is not an error
+	require.NoError(t, cache.Store(key, sourceDir))
+
+	restored, err = cache.Restore(key, targetDir)
+	require.NoError(t, err)
+	assert.True(t, restored)
+
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"pod.go", "swagger.json"}, names)
+
+	data, err := os.ReadFile(filepath.Join(targetDir, "pod.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package v1\n", string(data))
+
+	assert.Equal(t, ModelCacheStats{Hits: 1, Misses: 1}, cache.Stats())
+}
+
+func TestModelCacheKey(t *testing.T) {
+	cache, err := NewModelCache(t.TempDir(), "v1.0.0")
+	require.NoError(t, err)
+	otherVersion, err := NewModelCache(t.TempDir(), "v1.1.0")
+	require.NoError(t, err)
+
+	swaggerData := []byte(`{"swagger": "2.0"}`)
+	key := cache.Key("fingerprint", "api/core/v1", swaggerData)
+
+	assert.Equal(t, key, cache.Key("fingerprint", "api/core/v1", swaggerData))
+	assert.NotEqual(t, key, cache.Key("fingerprint", "api/apps/v1", swaggerData))
+	assert.NotEqual(t, key, cache.Key("fingerprint", "api/core/v1", []byte(`{"swagger": "2.0", "info": {}}`)))
+	assert.NotEqual(t, key, cache.Key("other fingerprint", "api/core/v1", swaggerData))
+	assert.NotEqual(t, key, otherVersion.Key("fingerprint", "api/core/v1", swaggerData))
+}
@@ -0,0 +1,148 @@
+package split
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/kubewarden/k8s-objects-generator/object_templates"
+)
+
+// isInsideOfModule returns true when the import path belongs to the module.
+func isInsideOfModule(importPath, modulePath string) bool {
+	return importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/")
+}
+
+// rewriteModuleImports replaces the `from` module path with `to` inside of
+// the imports of the Go source. The source is returned untouched when it
+// doesn't import the `from` module.
+func rewriteModuleImports(name string, source []byte, from, to string) ([]byte, error) {
+	if from == to {
+		return source, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, source, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", name)
+	}
+
+	rewritten := false
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid import inside of %s", name)
+		}
+		if isInsideOfModule(importPath, from) {
+			spec.Path.Value = strconv.Quote(to + strings.TrimPrefix(importPath, from))
+			rewritten = true
+		}
+	}
+	if !rewritten {
+		return source, nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, errors.Wrapf(err, "cannot format %s", name)
+	}
+	return buf.Bytes(), nil
+}
+
+// isStandardLibrary returns true when the import path belongs to the
+// standard library, whose first element has no dot, unlike the one of the
+// modules.
+func isStandardLibrary(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// ImportRules are the imports allowed inside of the Go files of the project,
+// besides the standard library and the packages of the module.
+type ImportRules struct {
+	// Dependencies are the packages, or modules, the generated files can
+	// import
+	Dependencies []string
+	// HandWritten are the files, relative to the root of the project, that
+	// have not been generated, like the ones of the overlays. They can import
+	// any package, but the ones of the module the static files are written
+	// for
+	HandWritten []string
+}
+
+// allows returns true when the file can import the package.
+func (r *ImportRules) allows(file, importPath, gitRepo string) bool {
+	if isInsideOfModule(importPath, gitRepo) {
+		return true
+	}
+	if slices.Contains(r.HandWritten, file) {
+		return !isInsideOfModule(importPath, object_templates.StaticModulePath)
+	}
+	if isStandardLibrary(importPath) {
+		return true
+	}
+	return slices.ContainsFunc(r.Dependencies, func(dependency string) bool {
+		return isInsideOfModule(importPath, dependency)
+	})
+}
+
+// CheckModuleImports verifies that the Go files found inside of `root` import
+// the generated packages via the module path of the project: the generated
+// files must import only the standard library, the packages of the module and
+// the dependencies of the rules. The hand-written files must not import the
+// module the static files and the templates are written for, unless it's the
+// one being generated.
+func CheckModuleImports(afs afero.Fs, root, gitRepo string, rules ImportRules) error {
+	violations := []string{}
+	err := afero.Walk(afs, root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		source, err := afero.ReadFile(afs, path)
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, source, parser.ImportsOnly)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse %s", path)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid import inside of %s", path)
+			}
+			if !rules.allows(rel, importPath, gitRepo) {
+				violations = append(violations, fmt.Sprintf("%s imports %s", rel, importPath))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot check the imports of %s", root)
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("the generated files must import the packages of %s only:\n  - %s",
+			gitRepo, strings.Join(violations, "\n  - "))
+	}
+	return nil
+}
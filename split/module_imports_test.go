@@ -0,0 +1,101 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const staticSource = `// Package helpers is a static file.
+package helpers
+
+import (
+	"fmt"
+
+	// the schema package
+	"github.com/kubewarden/k8s-objects/apimachinery/pkg/runtime/schema"
+	other "github.com/kubewarden/k8s-objects-other/pkg"
+)
+
+var _ = fmt.Sprint(schema.GroupVersion{}, other.Value)
+`
+
+func TestRewriteModuleImports(t *testing.T) {
+	rewritten, err := rewriteModuleImports("helpers.go", []byte(staticSource),
+		"github.com/kubewarden/k8s-objects", "example.com/fork/k8s-objects")
+	require.NoError(t, err)
+
+	expected := `// Package helpers is a static file.
+package helpers
+
+import (
+	"fmt"
+
+	// the schema package
+	"example.com/fork/k8s-objects/apimachinery/pkg/runtime/schema"
+	other "github.com/kubewarden/k8s-objects-other/pkg"
+)
+
+var _ = fmt.Sprint(schema.GroupVersion{}, other.Value)
+`
+	assert.Equal(t, expected, string(rewritten))
+
+	// the files that don't import the module are not reformatted
+	source := []byte("package helpers\n\nimport   \"fmt\"\n")
+	rewritten, err = rewriteModuleImports("plain.go", source, "github.com/kubewarden/k8s-objects", "example.com/fork/k8s-objects")
+	require.NoError(t, err)
+	assert.Equal(t, string(source), string(rewritten))
+}
+
+func TestCheckModuleImports(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/out/api/core/v1/pod.go",
+		[]byte(`package v1
+
+import (
+	"encoding/json"
+
+	"example.com/fork/k8s-objects/apimachinery/pkg/runtime/schema"
+	"github.com/go-openapi/strfmt"
+)
+`), 0o600))
+	require.NoError(t, afero.WriteFile(afs, "/out/api/core/v1/swagger.json", []byte("{}"), 0o600))
+	rules := ImportRules{Dependencies: []string{"github.com/go-openapi/strfmt"}}
+
+	require.NoError(t, CheckModuleImports(afs, "/out", "example.com/fork/k8s-objects", rules))
+
+	// the generated files can import the allowed dependencies only
+	err := CheckModuleImports(afs, "/out", "example.com/fork/k8s-objects", ImportRules{})
+	require.EqualError(t, err, "the generated files must import the packages of example.com/fork/k8s-objects only:\n"+
+		"  - api/core/v1/pod.go imports github.com/go-openapi/strfmt")
+
+	require.NoError(t, afero.WriteFile(afs, "/out/helpers/helpers.go", []byte(staticSource), 0o600))
+	err = CheckModuleImports(afs, "/out", "example.com/fork/k8s-objects", rules)
+	require.EqualError(t, err, "the generated files must import the packages of example.com/fork/k8s-objects only:\n"+
+		"  - helpers/helpers.go imports github.com/kubewarden/k8s-objects/apimachinery/pkg/runtime/schema\n"+
+		"  - helpers/helpers.go imports github.com/kubewarden/k8s-objects-other/pkg")
+
+	// the hand-written files can import other modules, but not the upstream one
+	rules.HandWritten = []string{"helpers/helpers.go"}
+	err = CheckModuleImports(afs, "/out", "example.com/fork/k8s-objects", rules)
+	require.EqualError(t, err, "the generated files must import the packages of example.com/fork/k8s-objects only:\n"+
+		"  - helpers/helpers.go imports github.com/kubewarden/k8s-objects/apimachinery/pkg/runtime/schema")
+}
+
+func TestCheckModuleImportsDefaultRepository(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(afs, "/out/api/core/v1/pod.go",
+		[]byte("package v1\n\nimport \"github.com/kubewarden/k8s-objects/apimachinery/pkg/runtime/schema\"\n"), 0o600))
+
+	require.NoError(t, CheckModuleImports(afs, "/out", "github.com/kubewarden/k8s-objects", ImportRules{}))
+
+	// a stale module path is reported even when generating the default
+	// repository
+	require.NoError(t, afero.WriteFile(afs, "/out/api/core/v1/node.go",
+		[]byte("package v1\n\nimport \"example.com/old/k8s-objects/apimachinery/pkg/runtime/schema\"\n"), 0o600))
+	err := CheckModuleImports(afs, "/out", "github.com/kubewarden/k8s-objects", ImportRules{})
+	require.EqualError(t, err, "the generated files must import the packages of github.com/kubewarden/k8s-objects only:\n"+
+		"  - api/core/v1/node.go imports example.com/old/k8s-objects/apimachinery/pkg/runtime/schema")
+}
@@ -0,0 +1,189 @@
+package split
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// OverlayPolicy defines what happens when a file of an overlay already exists
+// inside of the output directory.
+type OverlayPolicy string
+
+const (
+	// OverlayReplace replaces the existing file with the one of the overlay
+	OverlayReplace OverlayPolicy = "replace"
+	// OverlayAdd keeps the existing file, only the new files of the overlay
+	// are added
+	OverlayAdd OverlayPolicy = "add"
+	// OverlayFail aborts, without copying any file
+	OverlayFail OverlayPolicy = "fail"
+)
+
+// ParseOverlayPolicy validates the name of a policy.
+func ParseOverlayPolicy(value string) (OverlayPolicy, error) {
+	policy := OverlayPolicy(value)
+	switch policy {
+	case OverlayReplace, OverlayAdd, OverlayFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overlay policy '%s', it must be one of %s, %s or %s", value, OverlayReplace, OverlayAdd, OverlayFail)
+	}
+}
+
+// Overlay is a directory of hand-written files merged into the output
+// directory after the generation, keeping their relative paths.
+type Overlay struct {
+	Dir    string
+	Policy OverlayPolicy
+}
+
+// OverlayFile is a file of an overlay.
+type OverlayFile struct {
+	// Path of the file, relative to the output directory
+	Path    string
+	Overlay string
+}
+
+// OverlayReport lists the files copied from the overlays.
+type OverlayReport struct {
+	// Added are the new files
+	Added []OverlayFile
+	// Shadowed are the existing files replaced by the ones of the overlays
+	Shadowed []OverlayFile
+	// Skipped are the files of the overlays that have not been copied because
+	// the output directory already has them
+	Skipped []OverlayFile
+}
+
+// overlayCopy is a file to be copied from an overlay.
+type overlayCopy struct {
+	source string
+	target string
+}
+
+// ApplyOverlays merges the overlays into `root`, in order. All the conflicts
+// are checked before copying any file: when an overlay using the fail policy
+// conflicts with an existing file, or with a file of a previous overlay,
+// nothing is copied.
+func ApplyOverlays(afs afero.Fs, root string, overlays []Overlay) (*OverlayReport, error) {
+	report := OverlayReport{}
+	copies := []overlayCopy{}
+	conflicts := []string{}
+	// files added by the previous overlays
+	added := map[string]bool{}
+
+	for _, overlay := range overlays {
+		files, err := overlayFiles(afs, overlay.Dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			target := filepath.Join(root, file)
+			exists := added[file]
+			if !exists {
+				if exists, err = afero.Exists(afs, target); err != nil {
+					return nil, errors.Wrapf(err, "cannot access %s", target)
+				}
+			}
+			overlayFile := OverlayFile{Path: file, Overlay: overlay.Dir}
+
+			switch {
+			case !exists:
+				report.Added = append(report.Added, overlayFile)
+			case overlay.Policy == OverlayReplace:
+				report.Shadowed = append(report.Shadowed, overlayFile)
+			case overlay.Policy == OverlayAdd:
+				report.Skipped = append(report.Skipped, overlayFile)
+				continue
+			default:
+				conflicts = append(conflicts, fmt.Sprintf("%s (overlay %s)", file, overlay.Dir))
+				continue
+			}
+
+			added[file] = true
+			copies = append(copies, overlayCopy{source: filepath.Join(overlay.Dir, file), target: target})
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("the overlays conflict with existing files:\n  - %s", strings.Join(conflicts, "\n  - "))
+	}
+
+	for _, c := range copies {
+		if err := copyOverlayFile(afs, c.source, c.target); err != nil {
+			return nil, err
+		}
+	}
+
+	return &report, nil
+}
+
+// overlayFiles returns the sorted paths of the regular files of the overlay,
+// relative to its directory.
+func overlayFiles(afs afero.Fs, dir string) ([]string, error) {
+	files := []string{}
+	err := afero.Walk(afs, dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read overlay %s", dir)
+	}
+
+	slices.Sort(files)
+	return files, nil
+}
+
+func copyOverlayFile(afs afero.Fs, source, target string) error {
+	data, err := afero.ReadFile(afs, source)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read %s", source)
+	}
+	if err := afs.MkdirAll(filepath.Dir(target), 0o750); err != nil { //nolint:mnd // mnd doesn't support file octals yet
+		return errors.Wrapf(err, "cannot create directory %s", filepath.Dir(target))
+	}
+	if err := afero.WriteFile(afs, target, data, 0o600); err != nil { //nolint:mnd // mnd doesn't support file octals yet
+		return errors.Wrapf(err, "cannot write %s", target)
+	}
+	return nil
+}
+
+// Files returns the paths of the files copied from the overlays.
+func (r *OverlayReport) Files() []string {
+	files := make([]string, 0, len(r.Added)+len(r.Shadowed))
+	for _, file := range slices.Concat(r.Added, r.Shadowed) {
+		files = append(files, file.Path)
+	}
+	return files
+}
+
+// Log logs the files copied from the overlays, and the generated files they
+// shadowed.
+func (r *OverlayReport) Log() {
+	for _, file := range r.Shadowed {
+		slog.Warn("File shadowed by overlay", "path", file.Path, "overlay", file.Overlay)
+	}
+	for _, file := range r.Skipped {
+		slog.Info("File of overlay skipped, the file already exists", "path", file.Path, "overlay", file.Overlay)
+	}
+	slog.Info("Overlays applied", "added", len(r.Added), "shadowed", len(r.Shadowed), "skipped", len(r.Skipped))
+}
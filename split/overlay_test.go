@@ -0,0 +1,88 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOverlayFs(t *testing.T) afero.Fs {
+	t.Helper()
+
+	afs := afero.NewMemMapFs()
+	for path, content := range map[string]string{
+		"/out/api/core/v1/pod.go":            "generated pod",
+		"/out/api/core/v1/service.go":        "generated service",
+		"/helpers/api/core/v1/pod.go":        "hand-written pod",
+		"/helpers/api/core/v1/pod_helper.go": "pod helper",
+		"/more/api/core/v1/service.go":       "hand-written service",
+		"/more/api/core/v1/pod_helper.go":    "other pod helper",
+	} {
+		require.NoError(t, afero.WriteFile(afs, path, []byte(content), 0o600))
+	}
+	return afs
+}
+
+func assertFileContent(t *testing.T, afs afero.Fs, path, expected string) {
+	t.Helper()
+
+	content, err := afero.ReadFile(afs, path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(content), "unexpected content of %s", path)
+}
+
+func TestApplyOverlays(t *testing.T) {
+	afs := newOverlayFs(t)
+
+	report, err := ApplyOverlays(afs, "/out", []Overlay{
+		{Dir: "/helpers", Policy: OverlayReplace},
+		{Dir: "/more", Policy: OverlayAdd},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []OverlayFile{{Path: "api/core/v1/pod_helper.go", Overlay: "/helpers"}}, report.Added)
+	assert.Equal(t, []OverlayFile{{Path: "api/core/v1/pod.go", Overlay: "/helpers"}}, report.Shadowed)
+	assert.Equal(t, []OverlayFile{
+		{Path: "api/core/v1/pod_helper.go", Overlay: "/more"},
+		{Path: "api/core/v1/service.go", Overlay: "/more"},
+	}, report.Skipped)
+	assert.Equal(t, []string{"api/core/v1/pod_helper.go", "api/core/v1/pod.go"}, report.Files())
+
+	assertFileContent(t, afs, "/out/api/core/v1/pod.go", "hand-written pod")
+	assertFileContent(t, afs, "/out/api/core/v1/pod_helper.go", "pod helper")
+	assertFileContent(t, afs, "/out/api/core/v1/service.go", "generated service")
+}
+
+func TestApplyOverlaysFail(t *testing.T) {
+	afs := newOverlayFs(t)
+
+	_, err := ApplyOverlays(afs, "/out", []Overlay{
+		{Dir: "/more", Policy: OverlayReplace},
+		{Dir: "/helpers", Policy: OverlayFail},
+	})
+	require.EqualError(t, err, "the overlays conflict with existing files:\n"+
+		"  - api/core/v1/pod.go (overlay /helpers)\n"+
+		"  - api/core/v1/pod_helper.go (overlay /helpers)")
+
+	// nothing is copied
+	assertFileContent(t, afs, "/out/api/core/v1/service.go", "generated service")
+	exists, err := afero.Exists(afs, "/out/api/core/v1/pod_helper.go")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestApplyOverlaysMissingDir(t *testing.T) {
+	_, err := ApplyOverlays(newOverlayFs(t), "/out", []Overlay{{Dir: "/missing", Policy: OverlayFail}})
+	require.ErrorContains(t, err, "cannot read overlay /missing")
+}
+
+func TestParseOverlayPolicy(t *testing.T) {
+	policy, err := ParseOverlayPolicy("add")
+	require.NoError(t, err)
+	assert.Equal(t, OverlayAdd, policy)
+
+	_, err = ParseOverlayPolicy("merge")
+	require.EqualError(t, err, "unknown overlay policy 'merge', it must be one of replace, add or fail")
+}
@@ -0,0 +1,172 @@
+package split
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+// PlanReport describes a refactoring plan. All the lists are sorted, the
+// report of the same swagger file is always the same and can be diffed.
+type PlanReport struct {
+	SwaggerVersion    string          `json:"swaggerVersion"`
+	KubernetesVersion string          `json:"kubernetesVersion"`
+	Packages          []PackageReport `json:"packages"`
+	// Patches lists the schemas patched before splitting the definitions
+	Patches []swaggerhelpers.PatchMatch `json:"patches,omitempty"`
+}
+
+// PackageReport describes a package of the refactoring plan.
+type PackageReport struct {
+	Name  string       `json:"name"`
+	Types []TypeReport `json:"types"`
+	// Interfaces are the types of the package generated as `json.RawMessage`
+	Interfaces []string `json:"interfaces"`
+	// Dependencies are the packages this one imports
+	Dependencies []string `json:"dependencies"`
+	// Sources are the inputs that provided the definitions of the package
+	Sources []string    `json:"sources,omitempty"`
+	GVKs    []GVKReport `json:"gvks"`
+}
+
+// TypeReport describes a type of a package.
+type TypeReport struct {
+	Name string `json:"name"`
+	// ID of the definition inside of the original swagger file
+	ID string `json:"id"`
+	// GoType is the existing type replacing the definition, which is not
+	// generated
+	GoType string `json:"goType,omitempty"`
+}
+
+// GVKReport is a Kubernetes kind provided by a package.
+type GVKReport struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Type is the name of the type implementing the kind
+	Type string `json:"type"`
+}
+
+// Report returns the description of the plan.
+func (r *RefactoringPlan) Report() PlanReport {
+	report := PlanReport{
+		SwaggerVersion:    r.SwaggerVersion,
+		KubernetesVersion: r.KubernetesVersion,
+		Packages:          make([]PackageReport, 0, len(r.Packages)),
+		Patches:           r.Patches,
+	}
+
+	for _, pkgName := range sortedKeys(r.Packages) {
+		pkg := r.Packages[pkgName]
+
+		pkgReport := PackageReport{
+			Name:         pkgName,
+			Types:        make([]TypeReport, 0, len(pkg.Definitions)),
+			Interfaces:   r.Interfaces.Interfaces(pkgName),
+			Dependencies: pkg.Dependencies.ToSlice(),
+			Sources:      r.Sources[pkgName],
+			GVKs:         []GVKReport{},
+		}
+		slices.Sort(pkgReport.Dependencies)
+
+		for _, dfn := range pkg.Definitions {
+			typeReport := TypeReport{Name: dfn.TypeName, ID: dfn.ID}
+			if override, found := r.TypeOverrides.Lookup(dfn.ID); found {
+				typeReport.GoType = override.GoType()
+				pkgReport.Types = append(pkgReport.Types, typeReport)
+				// the kinds are implemented by the generated types only
+				continue
+			}
+			pkgReport.Types = append(pkgReport.Types, typeReport)
+
+			if gvk, _ := definitionGroupKindResource(dfn); gvk != nil {
+				pkgReport.GVKs = append(pkgReport.GVKs, GVKReport{
+					Group:   gvk.Group,
+					Version: gvk.Version,
+					Kind:    gvk.Kind,
+					Type:    dfn.TypeName,
+				})
+			}
+		}
+		slices.SortFunc(pkgReport.Types, func(a, b TypeReport) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		slices.SortFunc(pkgReport.GVKs, func(a, b GVKReport) int {
+			return strings.Compare(a.Type, b.Type)
+		})
+
+		report.Packages = append(report.Packages, pkgReport)
+	}
+
+	return report
+}
+
+// WriteJSON writes the report as an indented JSON document.
+func (p *PlanReport) WriteJSON(out io.Writer) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot encode plan report")
+	}
+
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
+
+// WriteTable writes the report as human readable tables: the first one lists
+// the packages, the second one the kinds they provide and the last one, when
+// any, the patched schemas.
+func (p *PlanReport) WriteTable(out io.Writer) error {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // padding of the table
+
+	fmt.Fprintln(writer, "PACKAGE\tTYPES\tGVKS\tINTERFACES\tDEPENDENCIES")
+	for _, pkg := range p.Packages {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\n",
+			pkg.Name, len(pkg.Types), len(pkg.GVKs), listOrNone(pkg.Interfaces), listOrNone(pkg.Dependencies))
+	}
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "GROUP\tVERSION\tKIND\tPACKAGE\tTYPE")
+	for _, pkg := range p.Packages {
+		for _, gvk := range pkg.GVKs {
+			group := gvk.Group
+			if group == "" {
+				group = "core"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", group, gvk.Version, gvk.Kind, pkg.Name, gvk.Type)
+		}
+	}
+
+	if len(p.Patches) > 0 {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "PATCH\tDEFINITION\tLOCATION")
+		for _, match := range p.Patches {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", match.Rule, match.Definition, match.Location)
+		}
+	}
+
+	return writer.Flush()
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
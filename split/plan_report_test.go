@@ -0,0 +1,49 @@
+package split
+
+import (
+	"bytes"
+	_ "embed"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+//go:embed testdata/plan.json.gold
+var planJSONGold string
+
+//go:embed testdata/plan.txt.gold
+var planTableGold string
+
+func TestPlanReport(t *testing.T) {
+	splitter, err := NewSplitter(filepath.Join("testdata", "test-swagger.json"))
+	require.NoError(t, err)
+
+	omitEmpty := true
+	refactoringPlan, err := splitter.ComputeRefactoringPlan(swaggerhelpers.DefaultPackageMapping(), []swaggerhelpers.DefinitionPatch{
+		{
+			Name:       "omitempty-series",
+			Definition: "io.k8s.api.events.v1.Event",
+			Path:       "$.properties.series",
+			OmitEmpty:  &omitEmpty,
+		},
+	})
+	require.NoError(t, err)
+	refactoringPlan.Interfaces.RegisterInterface("api/events/v1", "EventSeries")
+	refactoringPlan.AssignSources(map[string][]string{
+		"io.k8s.api.events.v1.Event": {"swagger.json"},
+	})
+
+	report := refactoringPlan.Report()
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, report.WriteJSON(&jsonOutput))
+	assert.Equal(t, planJSONGold, jsonOutput.String())
+
+	var tableOutput bytes.Buffer
+	require.NoError(t, report.WriteTable(&tableOutput))
+	assert.Equal(t, planTableGold, tableOutput.String())
+}
@@ -7,54 +7,171 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
-	"strings"
-	"text/template"
 
 	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
 
-	"github.com/kubewarden/k8s-objects-generator/common"
 	"github.com/kubewarden/k8s-objects-generator/object_templates"
 )
 
+// Layout defines where the module is placed inside of the output directory.
+type Layout string
+
+const (
+	// LayoutGOPATH places the module inside of `<output>/src/<module path>`,
+	// the output directory is the GOPATH of the go commands
+	LayoutGOPATH Layout = "gopath"
+	// LayoutModule makes the output directory the root of the module, the
+	// go commands use the environment of the generator
+	LayoutModule Layout = "module"
+)
+
+// ParseLayout validates the name of a layout.
+func ParseLayout(value string) (Layout, error) {
+	layout := Layout(value)
+	switch layout {
+	case LayoutGOPATH, LayoutModule:
+		return layout, nil
+	default:
+		return "", fmt.Errorf("unknown layout '%s', it must be either %s or %s", value, LayoutGOPATH, LayoutModule)
+	}
+}
+
 type Project struct {
-	OutputDir           string
-	GitRepo             string
-	SwaggerTemplatesDir string
-	Root                string
+	OutputDir string
+	GitRepo   string
+	// Root is the directory where the files are generated. Between Init and
+	// Commit it's a staging directory, next to Target
+	Root string
+	// Target is the directory holding the generated files once the
+	// generation is committed
+	Target string
+	Layout Layout
+	// Force allows Init to replace a target directory that has not been
+	// created by the generator
+	Force bool
+	// ExistingModule is true when the packages are generated inside of a
+	// directory of an existing Go module, see NewModuleProject
+	ExistingModule bool
+	// GoModule defines the go.mod file of the module, it's not used when
+	// generating inside of an existing module
+	GoModule GoModule
+	// verifyError is the error of the last call to Verify, Commit refuses
+	// to replace the target with packages that failed the verification
+	verifyError error
 }
 
-func NewProject(outputDir, gitRepo, swaggerTemplatesDir string) (Project, error) {
+// NewProject returns a project generating a new module, placed inside of the
+// output directory according to the layout.
+func NewProject(outputDir, gitRepo string, layout Layout) (Project, error) {
 	absOut, err := filepath.Abs(outputDir)
 	if err != nil {
 		return Project{}, errors.Wrapf(err, "cannot calculate absolute path of %s", outputDir)
 	}
 
-	root := filepath.Join(absOut, "src", gitRepo)
+	root := absOut
+	if layout == LayoutGOPATH {
+		root = filepath.Join(absOut, "src", gitRepo)
+	}
+
+	return Project{
+		OutputDir: outputDir,
+		GitRepo:   gitRepo,
+		Root:      root,
+		Target:    root,
+		Layout:    layout,
+		GoModule:  DefaultGoModule(),
+	}, nil
+}
+
+// NewModuleProject returns a project generating the packages inside of `dir`,
+// which belongs to an existing Go module. The import path of the packages is
+// computed from the path of the module and the location of `dir` inside of
+// it.
+func NewModuleProject(dir string) (Project, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return Project{}, errors.Wrapf(err, "cannot calculate absolute path of %s", dir)
+	}
+
+	moduleRoot := absDir
+	for {
+		if _, err := os.Stat(filepath.Join(moduleRoot, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(moduleRoot)
+		if parent == moduleRoot {
+			return Project{}, fmt.Errorf("cannot find the go.mod file of the module containing %s", absDir)
+		}
+		moduleRoot = parent
+	}
+	if moduleRoot == absDir {
+		return Project{}, fmt.Errorf("%s is the root of a Go module, the packages must be generated inside of one of its directories", absDir)
+	}
+
+	goModFileName := filepath.Join(moduleRoot, "go.mod")
+	goMod, err := os.ReadFile(goModFileName)
+	if err != nil {
+		return Project{}, errors.Wrapf(err, "cannot read %s", goModFileName)
+	}
+	modulePath := modfile.ModulePath(goMod)
+	if modulePath == "" {
+		return Project{}, fmt.Errorf("cannot find the module path inside of %s", goModFileName)
+	}
+
+	relDir, err := filepath.Rel(moduleRoot, absDir)
+	if err != nil {
+		return Project{}, errors.Wrapf(err, "cannot calculate path of %s inside of the module", absDir)
+	}
 
 	return Project{
-		OutputDir:           outputDir,
-		GitRepo:             gitRepo,
-		SwaggerTemplatesDir: swaggerTemplatesDir,
-		Root:                root,
+		OutputDir:      absDir,
+		GitRepo:        path.Join(modulePath, filepath.ToSlash(relDir)),
+		Root:           absDir,
+		Target:         absDir,
+		ExistingModule: true,
 	}, nil
 }
 
+// Init prepares the staging directory where the files are generated. The
+// target directory is left untouched until Commit, but it must be either
+// empty or created by a previous run of the generator, unless Force is set.
 func (p *Project) Init(swaggerData []byte, kubernetesVersion, license string) error {
+	if err := p.checkTarget(); err != nil {
+		return err
+	}
+
+	var goMod []byte
+	if !p.ExistingModule {
+		var err error
+		if goMod, err = p.GoModule.GoModFile(p.GitRepo); err != nil {
+			return errors.Wrap(err, "invalid go.mod settings")
+		}
+	}
+
+	p.Root = stagingDir(p.Target)
+	p.verifyError = nil
 	err := os.RemoveAll(p.Root)
 	if err != nil && !os.IsNotExist(err) {
-		return errors.Wrapf(err, "cannot cleanup dir %s", p.Root)
+		return errors.Wrapf(err, "cannot cleanup staging dir %s", p.Root)
 	}
 
 	if err = os.MkdirAll(p.Root, 0o750); err != nil {
 		return errors.Wrapf(err, "cannot create dir %s", p.Root)
 	}
+	slog.Info("Generating into staging directory", "path", p.Root, "target", p.Target)
+
+	if p.ExistingModule {
+		return p.initInsideOfModule(swaggerData, kubernetesVersion)
+	}
 
 	goModFileName := filepath.Join(p.Root, "go.mod")
-	if err = goModInit(goModFileName, p.GitRepo); err != nil {
+	if err = os.WriteFile(goModFileName, goMod, 0o600); err != nil {
 		return errors.Wrapf(err, "cannot create go.mod file %s", goModFileName)
 	}
-	slog.Info("Created `go.mod`", "path", goModFileName)
+	slog.Info("Created `go.mod`", "path", goModFileName, "go", p.GoModule.Go, "toolchain", p.GoModule.Toolchain)
 
 	swaggerFileName := p.SwaggerFile()
 	if err = os.WriteFile(swaggerFileName, swaggerData, 0o600); err != nil {
@@ -67,6 +184,10 @@ func (p *Project) Init(swaggerData []byte, kubernetesVersion, license string) er
 		return errors.Wrapf(err, "cannot write KUBERNETES_VERSION file %s", kubernetesVersionFile)
 	}
 
+	if err = writeMetadata(p.Root, Metadata{KubernetesVersion: kubernetesVersion, GoModule: &p.GoModule}); err != nil {
+		return err
+	}
+
 	licenseFile := filepath.Join(p.Root, "LICENSE")
 	err = os.WriteFile(licenseFile, []byte(license), 0o600)
 	if err != nil {
@@ -88,40 +209,127 @@ func (p *Project) Init(swaggerData []byte, kubernetesVersion, license string) er
 	return nil
 }
 
-func (p *Project) SwaggerFile() string {
-	return filepath.Join(p.Root, "swagger.json")
+// initInsideOfModule prepares the directory of an existing module: the
+// module-level files (go.mod, LICENSE,...) are left to the owner of the
+// module.
+func (p *Project) initInsideOfModule(swaggerData []byte, kubernetesVersion string) error {
+	kubernetesVersionFile := filepath.Join(p.Root, "KUBERNETES_VERSION")
+
+	swaggerFileName := p.SwaggerFile()
+	if err := os.WriteFile(swaggerFileName, swaggerData, 0o600); err != nil {
+		return errors.Wrapf(err, "cannot write swagger file inside of project root: %s", swaggerFileName)
+	}
+
+	if err := os.WriteFile(kubernetesVersionFile, []byte(kubernetesVersion), 0o600); err != nil {
+		return errors.Wrapf(err, "cannot write KUBERNETES_VERSION file %s", kubernetesVersionFile)
+	}
+
+	return writeMetadata(p.Root, Metadata{KubernetesVersion: kubernetesVersion})
 }
 
-const GO_MOD_TEMPLATE = `
-module {{ .Repository }}
+// checkTarget refuses to replace a target directory that is not empty and
+// doesn't hold the files marking the output of the generator. The
+// KUBERNETES_VERSION file marks the output of the versions of the generator
+// predating the metadata file.
+func (p *Project) checkTarget() error {
+	entries, err := os.ReadDir(p.Target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "cannot read dir %s", p.Target)
+	}
+	if len(entries) == 0 || p.Force {
+		return nil
+	}
 
-go 1.20
+	for _, marker := range []string{MetadataFileName, "KUBERNETES_VERSION"} {
+		if _, err := os.Stat(filepath.Join(p.Target, marker)); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("refusing to overwrite %s: the directory is not empty and it has not been created by k8s-objects-generator", p.Target)
+}
 
-replace github.com/go-openapi/strfmt => github.com/kubewarden/strfmt v0.1.3
-`
+// Commit replaces the target directory with the staging one. The `.git`
+// directory of the target is kept, which allows to generate the files right
+// inside of a checkout of the module. The previous output is restored when
+// the staging directory cannot be moved. The generated files are not
+// committed when their verification failed.
+func (p *Project) Commit() error {
+	if p.verifyError != nil {
+		return errors.Wrapf(p.verifyError, "the generated files are left inside of %s", p.Root)
+	}
+	if p.Root == p.Target {
+		return nil
+	}
 
-func goModInit(fileName, gitRepo string) error {
-	templateData := struct {
-		Repository string
-	}{
-		Repository: gitRepo,
+	previous := previousDir(p.Target)
+	if err := os.RemoveAll(previous); err != nil {
+		return errors.Wrapf(err, "cannot cleanup dir %s", previous)
 	}
 
-	goModTemplate, err := template.New("go.mod").Parse(GO_MOD_TEMPLATE)
-	if err != nil {
-		return err
+	_, err := os.Stat(p.Target)
+	targetExists := err == nil
+	gitDir := filepath.Join(p.Target, ".git")
+	_, err = os.Stat(gitDir)
+	keepGitDir := err == nil
+	if keepGitDir {
+		if err := os.Rename(gitDir, filepath.Join(p.Root, ".git")); err != nil {
+			return errors.Wrapf(err, "cannot move %s into the generated files", gitDir)
+		}
+	}
+	targetMoved := false
+	restore := func() {
+		if targetMoved {
+			if err := os.Rename(previous, p.Target); err != nil {
+				slog.Error("Cannot restore the previous output", "path", previous, "error", err)
+			}
+		}
+		if keepGitDir {
+			if err := os.Rename(filepath.Join(p.Root, ".git"), gitDir); err != nil {
+				slog.Error("Cannot restore the git directory", "path", gitDir, "error", err)
+			}
+		}
 	}
 
-	file, err := os.Create(fileName)
-	if err != nil {
-		return err
+	if targetExists {
+		if err := os.Rename(p.Target, previous); err != nil {
+			restore()
+			return errors.Wrapf(err, "cannot move the previous output %s", p.Target)
+		}
+		targetMoved = true
 	}
 
-	if err := goModTemplate.Execute(file, templateData); err != nil {
-		return err
+	if err := os.Rename(p.Root, p.Target); err != nil {
+		restore()
+		return errors.Wrapf(err, "cannot move the generated files into %s", p.Target)
 	}
+	p.Root = p.Target
+
+	if targetExists {
+		if err := os.RemoveAll(previous); err != nil {
+			return errors.Wrapf(err, "cannot remove the previous output %s", previous)
+		}
+	}
+	return nil
+}
+
+// stagingDir returns the directory where the files of `target` are
+// generated. It's hidden, and next to the target directory, so that they are
+// on the same filesystem and Commit can rename it.
+func stagingDir(target string) string {
+	return filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".staging")
+}
+
+// previousDir returns the directory holding the previous output while
+// Commit moves the staging directory.
+func previousDir(target string) string {
+	return filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".previous")
+}
 
-	return file.Close()
+func (p *Project) SwaggerFile() string {
+	return filepath.Join(p.Root, "swagger.json")
 }
 
 func (p *Project) RunGoModTidy() error {
@@ -131,18 +339,29 @@ func (p *Project) RunGoModTidy() error {
 }
 
 func (p *Project) runGo(args []string) error {
-	cmdName := "go"
+	return runCmd("go", args, p.goEnv(), p.Root)
+}
 
+// runGoOutput runs a go command, returning its combined output.
+func (p *Project) runGoOutput(args []string) ([]byte, error) {
+	return runCmdOutput("go", args, p.goEnv(), p.Root)
+}
+
+// goEnv returns the environment variables of the go commands.
+func (p *Project) goEnv() map[string]string {
 	extraEnv := make(map[string]string)
 
-	// override GOPATH
-	extraEnv["GOPATH"] = p.OutputDir
-	// Add PATH, needed to find the `go` binary
-	extraEnv["PATH"] = os.Getenv("PATH")
-	// Add HOME, needed to find the go cache directory
-	extraEnv["HOME"] = os.Getenv("HOME")
+	// the go commands of the other layouts inherit the whole environment
+	if p.Layout == LayoutGOPATH {
+		// override GOPATH
+		extraEnv["GOPATH"] = p.OutputDir
+		// Add PATH, needed to find the `go` binary
+		extraEnv["PATH"] = os.Getenv("PATH")
+		// Add HOME, needed to find the go cache directory
+		extraEnv["HOME"] = os.Getenv("HOME")
+	}
 
-	return runCmd(cmdName, args, extraEnv, p.Root)
+	return extraEnv
 }
 
 func (p *Project) RunGoGet(module string) error {
@@ -151,58 +370,30 @@ func (p *Project) RunGoGet(module string) error {
 	return p.runGo(args)
 }
 
-func (p *Project) InvokeSwaggerModelGenerator(packageName string) error {
-	cmdName := "swagger"
-
-	packageNameChunks := strings.Split(packageName, "/")
-	if len(packageNameChunks) < common.ChunkNumber {
-		return fmt.Errorf("package name %s doesn't have enough chunks", packageName)
-	}
-
-	targetDir := filepath.Join(
-		p.Root,
-		strings.Join(packageNameChunks[0:len(packageNameChunks)-1], "/"))
-	moduleName := packageNameChunks[len(packageNameChunks)-1]
-	swaggerFileName := filepath.Join(targetDir, moduleName, "swagger.json")
-
-	abbrs := []string{"HPA", "AWS", "CSI", "FS", "FC", "GCE", "GRPC", "ISCSI", "NFS", "OS", "RBD", "SE", "IO", "CIDR"}
+func runCmd(cmdName string, args []string, extraEnv map[string]string, dir string) error {
+	var stdout, stderr bytes.Buffer
+	cmd := newCmd(cmdName, args, extraEnv, dir)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
-	args := []string{
-		"generate",
-		"model",
-	}
-	for _, abbr := range abbrs {
-		args = append(args, "--additional-initialism="+abbr)
+	err := cmd.Run()
+	if err != nil {
+		slog.Info("CMD output", "string", cmd)
+		slog.Info("STDOUT output", "string", stdout.String())
+		slog.Info("STDERR output", "string", stderr.String())
 	}
+	return err
+}
 
-	args = append(args,
-		[]string{
-			"--template-dir",
-			p.SwaggerTemplatesDir,
-			"--allow-template-override",
-			"-f",
-			swaggerFileName,
-			"-t",
-			targetDir,
-			"-m",
-			moduleName,
-		}...)
-
-	extraEnv := make(map[string]string)
-	extraEnv["GOPATH"] = p.OutputDir
-	// Add PATH, needed to find the `go` binary
-	extraEnv["PATH"] = os.Getenv("PATH")
-
-	return runCmd(cmdName, args, extraEnv, "")
+func runCmdOutput(cmdName string, args []string, extraEnv map[string]string, dir string) ([]byte, error) {
+	return newCmd(cmdName, args, extraEnv, dir).CombinedOutput()
 }
 
-func runCmd(cmdName string, args []string, extraEnv map[string]string, dir string) error {
+func newCmd(cmdName string, args []string, extraEnv map[string]string, dir string) *exec.Cmd {
 	cmd := exec.CommandContext(context.Background(), cmdName, args...)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
+	// the command inherits the environment when there are no extra
+	// variables
 	for key, value := range extraEnv {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 	}
@@ -211,11 +402,5 @@ func runCmd(cmdName string, args []string, extraEnv map[string]string, dir strin
 		cmd.Dir = dir
 	}
 
-	err := cmd.Run()
-	if err != nil {
-		slog.Info("CMD output", "string", cmd)
-		slog.Info("STDOUT output", "string", stdout.String())
-		slog.Info("STDERR output", "string", stderr.String())
-	}
-	return err
+	return cmd
 }
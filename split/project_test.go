@@ -0,0 +1,149 @@
+package split
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewModuleProject(t *testing.T) {
+	moduleRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(moduleRoot, "go.mod"), []byte("module example.com/policy\n\ngo 1.24\n"), 0o600))
+
+	project, err := NewModuleProject(filepath.Join(moduleRoot, "internal", "k8s"))
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/policy/internal/k8s", project.GitRepo)
+	assert.Equal(t, filepath.Join(moduleRoot, "internal", "k8s"), project.Root)
+	assert.True(t, project.ExistingModule)
+
+	_, err = NewModuleProject(moduleRoot)
+	require.ErrorContains(t, err, "is the root of a Go module")
+}
+
+func TestProjectInit(t *testing.T) {
+	project, err := NewProject(t.TempDir(), "example.com/objects", LayoutGOPATH)
+	require.NoError(t, err)
+	project.GoModule.Go = "1.24"
+	project.GoModule.Toolchain = "go1.24.2"
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+
+	goMod, err := os.ReadFile(filepath.Join(project.Root, "go.mod"))
+	require.NoError(t, err)
+	assert.Contains(t, string(goMod), "\ngo 1.24\n\ntoolchain go1.24.2\n")
+
+	metadata, err := os.ReadFile(filepath.Join(project.Root, MetadataFileName))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"kubernetesVersion": "1.33.0",
+		"goModule": {
+			"go": "1.24",
+			"toolchain": "go1.24.2",
+			"replace": [
+				{
+					"old": {"path": "github.com/go-openapi/strfmt"},
+					"new": {"path": "github.com/kubewarden/strfmt", "version": "v0.1.3"}
+				}
+			]
+		}
+	}`, string(metadata))
+
+	// invalid settings are reported before touching the output directory
+	require.NoError(t, project.Commit())
+	project.GoModule.Go = "1.x"
+	require.ErrorContains(t, project.Init([]byte("{}"), "1.33.0", "license"), "invalid go version")
+	assert.FileExists(t, filepath.Join(project.Target, "go.mod"))
+}
+
+func TestModuleProjectInit(t *testing.T) {
+	moduleRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(moduleRoot, "go.mod"), []byte("module example.com/policy\n"), 0o600))
+
+	project, err := NewModuleProject(filepath.Join(moduleRoot, "k8s"))
+	require.NoError(t, err)
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+
+	// the files of a previous run are removed
+	staleFile := filepath.Join(project.Root, "api", "stale.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(staleFile), 0o750))
+	require.NoError(t, os.WriteFile(staleFile, []byte("package api\n"), 0o600))
+	require.NoError(t, project.Commit())
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+	require.NoError(t, project.Commit())
+	assert.NoFileExists(t, staleFile)
+	assert.FileExists(t, filepath.Join(project.Root, "KUBERNETES_VERSION"))
+	metadata, err := os.ReadFile(filepath.Join(project.Root, MetadataFileName))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kubernetesVersion": "1.33.0"}`, string(metadata))
+	assert.NoFileExists(t, filepath.Join(project.Root, "go.mod"))
+	assert.NoFileExists(t, filepath.Join(project.Root, "LICENSE"))
+
+	// directories not created by the generator are never removed
+	otherDir := filepath.Join(moduleRoot, "pkg")
+	require.NoError(t, os.MkdirAll(otherDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, "main.go"), []byte("package pkg\n"), 0o600))
+	project, err = NewModuleProject(otherDir)
+	require.NoError(t, err)
+	require.ErrorContains(t, project.Init([]byte("{}"), "1.33.0", "license"), "refusing to overwrite")
+	assert.FileExists(t, filepath.Join(otherDir, "main.go"))
+}
+
+func TestProjectCommit(t *testing.T) {
+	outputDir := t.TempDir()
+	project, err := NewProject(outputDir, "example.com/objects", LayoutGOPATH)
+	require.NoError(t, err)
+	require.NoError(t, project.Init([]byte("{}"), "1.32.0", "license"))
+	require.NoError(t, project.Commit())
+	assert.Equal(t, project.Target, project.Root)
+
+	// the previous output is kept until the generation is committed
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+	assert.NotEqual(t, project.Target, project.Root)
+	assert.DirExists(t, project.Root)
+	version, err := os.ReadFile(filepath.Join(project.Target, "KUBERNETES_VERSION"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.32.0", string(version))
+
+	require.NoError(t, project.Commit())
+	version, err = os.ReadFile(filepath.Join(project.Target, "KUBERNETES_VERSION"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.33.0", string(version))
+	assert.NoDirExists(t, stagingDir(project.Target))
+	assert.NoDirExists(t, previousDir(project.Target))
+
+	// directories not created by the generator are replaced only when forced
+	require.NoError(t, os.Remove(filepath.Join(project.Target, "KUBERNETES_VERSION")))
+	require.NoError(t, os.Remove(filepath.Join(project.Target, MetadataFileName)))
+	project, err = NewProject(outputDir, "example.com/objects", LayoutGOPATH)
+	require.NoError(t, err)
+	require.ErrorContains(t, project.Init([]byte("{}"), "1.33.0", "license"), "refusing to overwrite")
+	assert.NoDirExists(t, stagingDir(project.Target))
+	project.Force = true
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+	require.NoError(t, project.Commit())
+	assert.FileExists(t, filepath.Join(project.Target, MetadataFileName))
+}
+
+func TestModuleLayoutProject(t *testing.T) {
+	outputDir := t.TempDir()
+	project, err := NewProject(outputDir, "example.com/objects", LayoutModule)
+	require.NoError(t, err)
+	assert.Equal(t, outputDir, project.Target)
+
+	// the output directory can be a checkout of the module
+	require.NoError(t, os.MkdirAll(filepath.Join(outputDir, ".git"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "KUBERNETES_VERSION"), []byte("1.32.0"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "stale.go"), []byte("package objects\n"), 0o600))
+
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+	require.NoError(t, project.Commit())
+	assert.FileExists(t, filepath.Join(outputDir, "go.mod"))
+	assert.FileExists(t, filepath.Join(outputDir, ".git", "HEAD"))
+	assert.NoFileExists(t, filepath.Join(outputDir, "stale.go"))
+
+	_, err = ParseLayout("flat")
+	require.EqualError(t, err, "unknown layout 'flat', it must be either gopath or module")
+}
@@ -2,6 +2,8 @@ package split
 
 import (
 	"fmt"
+	"log/slog"
+	"slices"
 
 	openapi_spec "github.com/go-openapi/spec"
 	"github.com/heimdalr/dag"
@@ -15,9 +17,21 @@ type RefactoringPlan struct {
 	Interfaces        swaggerhelpers.InterfaceRegistry
 	SwaggerVersion    string
 	KubernetesVersion string
+	// Sources holds, for each package, the inputs that provided its
+	// definitions. It's populated by AssignSources
+	Sources map[string][]string
+	// Patches lists the schemas patched by the default and by the configured
+	// patches
+	Patches []swaggerhelpers.PatchMatch
+	// TypeOverrides are the definitions replaced by existing Go types. It's
+	// populated by OverrideTypes
+	TypeOverrides swaggerhelpers.TypeOverrides
 }
 
-func NewRefactoringPlan(swagger *openapi_spec.Swagger) (*RefactoringPlan, error) {
+// NewRefactoringPlan computes how the swagger file is going to be split, the
+// packages of the definitions are resolved using the given mapping. The
+// patches are applied to the definitions, after the default ones.
+func NewRefactoringPlan(swagger *openapi_spec.Swagger, mapping *swaggerhelpers.PackageMapping, patches []swaggerhelpers.DefinitionPatch) (*RefactoringPlan, error) {
 	packages := make(map[string]swaggerhelpers.Package)
 	interfaces := swaggerhelpers.NewInterfaceRegistry()
 
@@ -26,8 +40,28 @@ func NewRefactoringPlan(swagger *openapi_spec.Swagger) (*RefactoringPlan, error)
 		kubernetesVersion = swagger.Info.Version
 	}
 
-	for id, definition := range swagger.Definitions {
-		newDefinitionRefactoringPlan, err := swaggerhelpers.NewDefinition(definition, id)
+	// Some definitions need special tuning to work properly
+	defaultPatcher, err := swaggerhelpers.NewPatcher(swaggerhelpers.DefaultDefinitionPatches())
+	if err != nil {
+		return nil, err
+	}
+	patcher, err := swaggerhelpers.NewPatcher(patches)
+	if err != nil {
+		return nil, err
+	}
+
+	// the definitions are visited in order, the definitions of the packages
+	// and the patched schemas are always listed in the same order
+	for _, id := range sortedKeys(swagger.Definitions) {
+		definition := swagger.Definitions[id]
+		if err := defaultPatcher.Apply(id, &definition); err != nil {
+			return nil, err
+		}
+		if err := patcher.Apply(id, &definition); err != nil {
+			return nil, err
+		}
+
+		newDefinitionRefactoringPlan, err := swaggerhelpers.NewDefinition(definition, id, mapping)
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot parse definition with id %s", id)
 		}
@@ -48,14 +82,57 @@ func NewRefactoringPlan(swagger *openapi_spec.Swagger) (*RefactoringPlan, error)
 		packages[newDefinitionRefactoringPlan.PackageName] = pkg
 	}
 
+	for _, name := range patcher.Unused() {
+		slog.Warn("The patch didn't match any schema", "patch", name)
+	}
+
 	return &RefactoringPlan{
 		SwaggerVersion:    swagger.Swagger,
 		KubernetesVersion: kubernetesVersion,
 		Packages:          packages,
 		Interfaces:        interfaces,
+		Patches:           append(defaultPatcher.Matches(), patcher.Matches()...),
 	}, nil
 }
 
+// AssignSources computes the sources of each package, given the sources of
+// each definition id.
+func (r *RefactoringPlan) AssignSources(definitionSources map[string][]string) {
+	r.Sources = make(map[string][]string, len(r.Packages))
+
+	for pkgName, pkg := range r.Packages {
+		sources := []string{}
+		for _, definition := range pkg.Definitions {
+			for _, source := range definitionSources[definition.ID] {
+				if !slices.Contains(sources, source) {
+					sources = append(sources, source)
+				}
+			}
+		}
+		slices.Sort(sources)
+		r.Sources[pkgName] = sources
+	}
+}
+
+// OverrideTypes replaces the Go types of the definitions with existing ones:
+// the overridden definitions are not generated, the properties referencing
+// them use the existing types.
+func (r *RefactoringPlan) OverrideTypes(overrides []swaggerhelpers.TypeOverride) {
+	known := map[string]bool{}
+	for _, pkg := range r.Packages {
+		for _, definition := range pkg.Definitions {
+			known[definition.ID] = true
+		}
+	}
+	for _, override := range overrides {
+		if !known[override.Definition] {
+			slog.Warn("The overridden definition doesn't exist", "definition", override.Definition)
+		}
+	}
+
+	r.TypeOverrides = swaggerhelpers.NewTypeOverrides(overrides)
+}
+
 func (r *RefactoringPlan) DependenciesGraph() (*dag.DAG, error) {
 	dependenciesGraph := dag.NewDAG()
 
@@ -95,27 +172,24 @@ func ensureDependencyExists(graph *dag.DAG, dependency string, packages map[stri
 	return nil
 }
 
-func (r *RefactoringPlan) RenderNewSwaggerFiles(githubRepo string) (map[string]string, error) {
-	renderedFiles := make(map[string]string)
+// RenderNewSwaggers returns the swagger document of each package.
+func (r *RefactoringPlan) RenderNewSwaggers(githubRepo string) (map[string]openapi_spec.Swagger, error) {
+	swaggers := make(map[string]openapi_spec.Swagger)
 
 	for pkgName, pkg := range r.Packages {
-		swaggerFile, err := pkg.GenerateSwagger(
+		swagger, err := pkg.GenerateSwagger(
 			r.SwaggerVersion,
 			r.KubernetesVersion,
 			githubRepo,
 			&r.Interfaces,
+			r.TypeOverrides,
 		)
 		if err != nil {
-			return make(map[string]string), errors.Wrapf(err, "cannot render swagger file for package %s", pkgName)
-		}
-
-		jsonBytes, err := swaggerFile.MarshalJSON()
-		if err != nil {
-			return make(map[string]string), errors.Wrapf(err, "cannot render swagger file for package %s to JSON", pkgName)
+			return make(map[string]openapi_spec.Swagger), errors.Wrapf(err, "cannot render swagger file for package %s", pkgName)
 		}
 
-		renderedFiles[pkgName] = string(jsonBytes)
+		swaggers[pkgName] = swagger
 	}
 
-	return renderedFiles, nil
+	return swaggers, nil
 }
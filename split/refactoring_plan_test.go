@@ -1,9 +1,12 @@
 package split
 
 import (
+	"slices"
 	"testing"
 
 	openapi_spec "github.com/go-openapi/spec"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
 )
 
 func TestNewRefactoringPlan(t *testing.T) {
@@ -46,7 +49,7 @@ func TestNewRefactoringPlan(t *testing.T) {
 		},
 	}
 
-	plan, err := NewRefactoringPlan(&swagger)
+	plan, err := NewRefactoringPlan(&swagger, swaggerhelpers.DefaultPackageMapping(), nil)
 	if err != nil {
 		t.Errorf("Cannot create refactoring plan: %v", err)
 	}
@@ -73,3 +76,35 @@ func TestNewRefactoringPlan(t *testing.T) {
 		t.Errorf("wrong number of packages found inside of the plan: %d", len(plan.Packages))
 	}
 }
+
+func TestRefactoringPlanIsStable(t *testing.T) {
+	swagger := openapi_spec.Swagger{}
+	swagger.Definitions = make(openapi_spec.Definitions)
+	for _, name := range []string{"Pod", "Node", "Service", "Namespace", "Event", "Secret", "ConfigMap", "Endpoints"} {
+		swagger.Definitions["io.k8s.api.core.v1."+name] = openapi_spec.Schema{
+			SchemaProps: openapi_spec.SchemaProps{
+				Type:       []string{"object"},
+				Properties: map[string]openapi_spec.Schema{"name": *openapi_spec.StringProperty()},
+			},
+		}
+	}
+
+	// the definitions are listed in the same order, whatever the iteration
+	// order of the maps
+	var expected []string
+	for range 10 {
+		plan, err := NewRefactoringPlan(&swagger, swaggerhelpers.DefaultPackageMapping(), nil)
+		if err != nil {
+			t.Fatalf("Cannot create refactoring plan: %v", err)
+		}
+		ids := []string{}
+		for _, dfn := range plan.Packages["api/core/v1"].Definitions {
+			ids = append(ids, dfn.ID)
+		}
+		if expected == nil {
+			expected = ids
+		} else if !slices.Equal(expected, ids) {
+			t.Fatalf("the definitions are listed in a different order: %v, %v", expected, ids)
+		}
+	}
+}
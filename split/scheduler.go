@@ -0,0 +1,152 @@
+package split
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/heimdalr/dag"
+	"github.com/pkg/errors"
+)
+
+// GenerationError holds the errors of all the packages that could not be
+// generated.
+type GenerationError struct {
+	// Errors maps the name of a package to the error that prevented its
+	// generation
+	Errors map[string]error
+}
+
+func (e *GenerationError) Error() string {
+	pkgNames := make([]string, 0, len(e.Errors))
+	for pkgName := range e.Errors {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "cannot generate %d package(s):", len(pkgNames))
+	for _, pkgName := range pkgNames {
+		fmt.Fprintf(&msg, "\n  %s: %v", pkgName, e.Errors[pkgName])
+	}
+	return msg.String()
+}
+
+// packageScheduler runs a task for each package of the dependencies graph,
+// using up to `jobs` workers. The task of a package is started only once the
+// tasks of all its dependencies have succeeded, the packages whose
+// dependencies failed are skipped.
+type packageScheduler struct {
+	graph *dag.DAG
+	jobs  int
+}
+
+func newPackageScheduler(graph *dag.DAG, jobs int) *packageScheduler {
+	if jobs < 1 {
+		jobs = 1
+	}
+	return &packageScheduler{
+		graph: graph,
+		jobs:  jobs,
+	}
+}
+
+type taskResult struct {
+	pkgName string
+	err     error
+}
+
+// Run invokes the task for all the packages and waits for their completion.
+// The returned error, if any, is a *GenerationError.
+func (s *packageScheduler) Run(task func(pkgName string) error) error {
+	pending := make(map[string]int)
+	ready := []string{}
+	for pkgName := range s.graph.GetVertices() {
+		dependencies, err := s.graph.GetParents(pkgName)
+		if err != nil {
+			return errors.Wrapf(err, "cannot find dependencies of package %s", pkgName)
+		}
+		pending[pkgName] = len(dependencies)
+		if len(dependencies) == 0 {
+			ready = append(ready, pkgName)
+		}
+	}
+
+	failures := make(map[string]error)
+	todo := make(chan string)
+	// buffered, the workers never block when Run returns early
+	results := make(chan taskResult, s.jobs)
+	for range s.jobs {
+		go func() {
+			for pkgName := range todo {
+				results <- taskResult{pkgName: pkgName, err: task(pkgName)}
+			}
+		}()
+	}
+	defer close(todo)
+
+	remaining := len(pending)
+	running := 0
+	for remaining > 0 {
+		// dispatch the ready packages in a stable order
+		sort.Strings(ready)
+		for len(ready) > 0 && running < s.jobs {
+			todo <- ready[0]
+			ready = ready[1:]
+			running++
+		}
+
+		result := <-results
+		running--
+		remaining--
+
+		dependents, err := s.graph.GetChildren(result.pkgName)
+		if err != nil {
+			return errors.Wrapf(err, "cannot find dependents of package %s", result.pkgName)
+		}
+
+		if result.err != nil {
+			failures[result.pkgName] = result.err
+			skipped, err := s.skipDependents(result.pkgName, failures)
+			if err != nil {
+				return err
+			}
+			remaining -= skipped
+			continue
+		}
+
+		for dependent := range dependents {
+			if _, failed := failures[dependent]; failed {
+				continue
+			}
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return &GenerationError{Errors: failures}
+	}
+	return nil
+}
+
+// skipDependents marks all the packages depending on the failed one as
+// failed. It returns how many packages have been skipped.
+func (s *packageScheduler) skipDependents(failedPkg string, failures map[string]error) (int, error) {
+	descendants, err := s.graph.GetDescendants(failedPkg)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot find dependents of package %s", failedPkg)
+	}
+
+	skipped := 0
+	for pkgName := range descendants {
+		if _, failed := failures[pkgName]; failed {
+			continue
+		}
+		failures[pkgName] = fmt.Errorf("skipped because dependency %s failed", failedPkg)
+		skipped++
+	}
+	return skipped, nil
+}
@@ -0,0 +1,99 @@
+package split
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/heimdalr/dag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildGraph returns a graph where each key depends on the packages listed as
+// value.
+func buildGraph(t *testing.T, dependencies map[string][]string) *dag.DAG {
+	t.Helper()
+
+	graph := dag.NewDAG()
+	for pkgName := range dependencies {
+		require.NoError(t, graph.AddVertexByID(pkgName, pkgName))
+	}
+	for pkgName, deps := range dependencies {
+		for _, dep := range deps {
+			require.NoError(t, graph.AddEdge(dep, pkgName))
+		}
+	}
+	return graph
+}
+
+//nolint:gochecknoglobals // test fixture
+var testDependencies = map[string][]string{
+	"apimachinery/pkg/apis/meta/v1": {},
+	"apimachinery/pkg/util/intstr":  {},
+	"api/core/v1":                   {"apimachinery/pkg/apis/meta/v1", "apimachinery/pkg/util/intstr"},
+	"api/apps/v1":                   {"api/core/v1", "apimachinery/pkg/apis/meta/v1"},
+	"api/batch/v1":                  {"api/core/v1"},
+	"api/rbac/v1":                   {"apimachinery/pkg/apis/meta/v1"},
+}
+
+func TestPackageSchedulerRespectsDependencies(t *testing.T) {
+	for _, jobs := range []int{1, 2, 8} {
+		graph := buildGraph(t, testDependencies)
+
+		var lock sync.Mutex
+		done := make(map[string]bool)
+
+		err := newPackageScheduler(graph, jobs).Run(func(pkgName string) error {
+			lock.Lock()
+			defer lock.Unlock()
+
+			for _, dep := range testDependencies[pkgName] {
+				assert.True(t, done[dep], "package %s started before its dependency %s (jobs: %d)", pkgName, dep, jobs)
+			}
+			done[pkgName] = true
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, done, len(testDependencies))
+	}
+}
+
+func TestPackageSchedulerAggregatesErrors(t *testing.T) {
+	graph := buildGraph(t, testDependencies)
+
+	var lock sync.Mutex
+	invoked := []string{}
+
+	err := newPackageScheduler(graph, 4).Run(func(pkgName string) error {
+		lock.Lock()
+		invoked = append(invoked, pkgName)
+		lock.Unlock()
+
+		switch pkgName {
+		case "api/core/v1", "api/rbac/v1":
+			return errors.New("boom")
+		default:
+			return nil
+		}
+	})
+
+	var generationErr *GenerationError
+	require.ErrorAs(t, err, &generationErr)
+	assert.Len(t, generationErr.Errors, 4)
+	assert.EqualError(t, generationErr.Errors["api/core/v1"], "boom")
+	assert.EqualError(t, generationErr.Errors["api/rbac/v1"], "boom")
+	assert.EqualError(t, generationErr.Errors["api/apps/v1"], "skipped because dependency api/core/v1 failed")
+	assert.EqualError(t, generationErr.Errors["api/batch/v1"], "skipped because dependency api/core/v1 failed")
+
+	assert.NotContains(t, invoked, "api/apps/v1")
+	assert.NotContains(t, invoked, "api/batch/v1")
+
+	// packages are reported in a stable order
+	assert.Equal(t, `cannot generate 4 package(s):
+  api/apps/v1: skipped because dependency api/core/v1 failed
+  api/batch/v1: skipped because dependency api/core/v1 failed
+  api/core/v1: boom
+  api/rbac/v1: boom`, err.Error())
+}
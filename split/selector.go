@@ -0,0 +1,171 @@
+package split
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+const (
+	packageSelectorPrefix = "package:"
+	gvSelectorPrefix      = "gv:"
+	gvkSelectorPrefix     = "gvk:"
+
+	// coreGroupName is the name used by the selectors for the core group,
+	// which has an empty name
+	coreGroupName = "core"
+)
+
+// Selector selects definitions of the refactoring plan, either:
+//   - by package: `package:api/apps/v1`, shell patterns like `package:api/*/v1`
+//     are supported
+//   - by group version: `gv:apps/v1`, the core group is written as `core/v1`
+//   - by kind: `gvk:apps/v1/Deployment`
+//
+// The group version and kind selectors match only the definitions that
+// declare a Kubernetes kind.
+type Selector struct {
+	value string
+
+	packagePattern string
+	group          string
+	version        string
+	kind           string
+}
+
+// ParseSelector parses the textual representation of a selector.
+func ParseSelector(value string) (Selector, error) {
+	selector := Selector{value: value}
+
+	switch {
+	case strings.HasPrefix(value, packageSelectorPrefix):
+		selector.packagePattern = strings.TrimPrefix(value, packageSelectorPrefix)
+		if _, err := path.Match(selector.packagePattern, ""); err != nil || selector.packagePattern == "" {
+			return Selector{}, fmt.Errorf("invalid selector '%s': malformed package pattern", value)
+		}
+	case strings.HasPrefix(value, gvSelectorPrefix):
+		parts := strings.Split(strings.TrimPrefix(value, gvSelectorPrefix), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" { //nolint:mnd // group and version
+			return Selector{}, fmt.Errorf("invalid selector '%s': expected gv:<group>/<version>", value)
+		}
+		selector.group, selector.version = parts[0], parts[1]
+	case strings.HasPrefix(value, gvkSelectorPrefix):
+		parts := strings.Split(strings.TrimPrefix(value, gvkSelectorPrefix), "/")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" { //nolint:mnd // group, version and kind
+			return Selector{}, fmt.Errorf("invalid selector '%s': expected gvk:<group>/<version>/<kind>", value)
+		}
+		selector.group, selector.version, selector.kind = parts[0], parts[1], parts[2]
+	default:
+		return Selector{}, fmt.Errorf("invalid selector '%s': it must start with one of %s, %s or %s",
+			value, packageSelectorPrefix, gvSelectorPrefix, gvkSelectorPrefix)
+	}
+
+	if selector.group == coreGroupName {
+		selector.group = ""
+	}
+
+	return selector, nil
+}
+
+func (s Selector) String() string {
+	return s.value
+}
+
+// Matches returns true when the definition is selected.
+func (s Selector) Matches(definition *swaggerhelpers.Definition) bool {
+	if s.packagePattern != "" {
+		matched, _ := path.Match(s.packagePattern, definition.PackageName)
+		return matched
+	}
+
+	gvk, _ := definitionGroupKindResource(definition)
+	if gvk == nil {
+		return false
+	}
+	return gvk.Group == s.group && gvk.Version == s.version && (s.kind == "" || gvk.Kind == s.kind)
+}
+
+// Select prunes the plan down to the definitions matched by the `include`
+// selectors, all the definitions are included when there are none. The
+// definitions matched by the `exclude` selectors are then removed.
+//
+// The definitions referenced by the selected ones are always kept, even when
+// they are excluded, otherwise the generated code would not compile.
+func (r *RefactoringPlan) Select(include, exclude []Selector) error {
+	definitions := make(map[string]*swaggerhelpers.Definition)
+	for _, pkg := range r.Packages {
+		for _, dfn := range pkg.Definitions {
+			definitions[dfn.ID] = dfn
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, selector := range include {
+		if !selectDefinitions(definitions, selector, selected, true) {
+			return fmt.Errorf("selector '%s' doesn't match any definition", selector)
+		}
+	}
+	if len(include) == 0 {
+		for id := range definitions {
+			selected[id] = true
+		}
+	}
+	for _, selector := range exclude {
+		if !selectDefinitions(definitions, selector, selected, false) {
+			return fmt.Errorf("selector '%s' doesn't match any definition", selector)
+		}
+	}
+
+	// add all the definitions transitively referenced by the selected ones
+	queue := make([]string, 0, len(selected))
+	for id := range selected {
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, reference := range definitions[id].References() {
+			if _, known := definitions[reference]; known && !selected[reference] {
+				selected[reference] = true
+				queue = append(queue, reference)
+			}
+		}
+	}
+
+	packages := make(map[string]swaggerhelpers.Package)
+	for _, pkgName := range sortedKeys(r.Packages) {
+		for _, dfn := range r.Packages[pkgName].Definitions {
+			if !selected[dfn.ID] {
+				continue
+			}
+			pkg, pkgKnown := packages[pkgName]
+			if !pkgKnown {
+				pkg = swaggerhelpers.NewPackage(pkgName)
+			}
+			pkg.AddDefinitionRefactoringPlan(dfn)
+			packages[pkgName] = pkg
+		}
+	}
+	r.Packages = packages
+
+	return nil
+}
+
+// selectDefinitions sets the state of the definitions matched by the
+// selector, it returns false when no definition is matched.
+func selectDefinitions(definitions map[string]*swaggerhelpers.Definition, selector Selector, selected map[string]bool, state bool) bool {
+	matched := false
+	for id, dfn := range definitions {
+		if selector.Matches(dfn) {
+			matched = true
+			if state {
+				selected[id] = true
+			} else {
+				delete(selected, id)
+			}
+		}
+	}
+	return matched
+}
@@ -0,0 +1,188 @@
+package split
+
+import (
+	"testing"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+const selectorTestSwagger = `{
+  "swagger": "2.0",
+  "info": {"title": "kubernetes", "version": "1.33"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "type": "object",
+      "properties": {
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1.DeploymentSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "version": "v1", "kind": "Deployment"}]
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "type": "object",
+      "properties": {
+        "template": {"$ref": "#/definitions/io.k8s.api.core.v1.PodTemplateSpec"}
+      }
+    },
+    "io.k8s.api.apps.v1.StatefulSet": {
+      "type": "object",
+      "properties": {
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "version": "v1", "kind": "StatefulSet"}]
+    },
+    "io.k8s.api.core.v1.Pod": {
+      "type": "object",
+      "properties": {
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "", "version": "v1", "kind": "Pod"}]
+    },
+    "io.k8s.api.core.v1.PodTemplateSpec": {
+      "type": "object",
+      "properties": {
+        "containers": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.core.v1.Container"}}
+      }
+    },
+    "io.k8s.api.core.v1.Container": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"}
+      }
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"}
+      }
+    }
+  }
+}`
+
+func newSelectorTestPlan(t *testing.T) *RefactoringPlan {
+	t.Helper()
+
+	swagger := openapi_spec.Swagger{}
+	require.NoError(t, swagger.UnmarshalJSON([]byte(selectorTestSwagger)))
+
+	plan, err := NewRefactoringPlan(&swagger, swaggerhelpers.DefaultPackageMapping(), nil)
+	require.NoError(t, err)
+	return plan
+}
+
+func parseTestSelectors(t *testing.T, values ...string) []Selector {
+	t.Helper()
+
+	selectors := []Selector{}
+	for _, value := range values {
+		selector, err := ParseSelector(value)
+		require.NoError(t, err)
+		selectors = append(selectors, selector)
+	}
+	return selectors
+}
+
+// planTypes returns the types of each package of the plan.
+func planTypes(plan *RefactoringPlan) map[string][]string {
+	types := make(map[string][]string)
+	for _, pkg := range plan.Report().Packages {
+		for _, tpe := range pkg.Types {
+			types[pkg.Name] = append(types[pkg.Name], tpe.Name)
+		}
+	}
+	return types
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name          string
+		include       []string
+		exclude       []string
+		expectedTypes map[string][]string
+	}{
+		{
+			name:    "kind with its transitive references",
+			include: []string{"gvk:apps/v1/Deployment"},
+			expectedTypes: map[string][]string{
+				"api/apps/v1":                   {"Deployment", "DeploymentSpec"},
+				"api/core/v1":                   {"Container", "PodTemplateSpec"},
+				"apimachinery/pkg/apis/meta/v1": {"ObjectMeta"},
+			},
+		},
+		{
+			name:    "core group version",
+			include: []string{"gv:core/v1"},
+			expectedTypes: map[string][]string{
+				"api/core/v1":                   {"Pod"},
+				"apimachinery/pkg/apis/meta/v1": {"ObjectMeta"},
+			},
+		},
+		{
+			name:    "package pattern with excluded kind",
+			include: []string{"package:api/apps/*"},
+			exclude: []string{"gvk:apps/v1/StatefulSet"},
+			expectedTypes: map[string][]string{
+				"api/apps/v1":                   {"Deployment", "DeploymentSpec"},
+				"api/core/v1":                   {"Container", "PodTemplateSpec"},
+				"apimachinery/pkg/apis/meta/v1": {"ObjectMeta"},
+			},
+		},
+		{
+			name:    "excluded definitions are kept when referenced",
+			exclude: []string{"package:api/core/v1", "gvk:apps/v1/StatefulSet"},
+			expectedTypes: map[string][]string{
+				"api/apps/v1":                   {"Deployment", "DeploymentSpec"},
+				"api/core/v1":                   {"Container", "PodTemplateSpec"},
+				"apimachinery/pkg/apis/meta/v1": {"ObjectMeta"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := newSelectorTestPlan(t)
+
+			err := plan.Select(parseTestSelectors(t, tt.include...), parseTestSelectors(t, tt.exclude...))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTypes, planTypes(plan))
+		})
+	}
+}
+
+func TestSelectRecomputesDependencies(t *testing.T) {
+	plan := newSelectorTestPlan(t)
+
+	require.NoError(t, plan.Select(parseTestSelectors(t, "gvk:apps/v1/StatefulSet"), nil))
+
+	// DeploymentSpec, which references the core/v1 package, is not selected
+	assert.ElementsMatch(t, []string{"apimachinery/pkg/apis/meta/v1"}, plan.Packages["api/apps/v1"].Dependencies.ToSlice())
+	_, err := plan.DependenciesGraph()
+	require.NoError(t, err)
+}
+
+func TestSelectUnmatchedSelector(t *testing.T) {
+	plan := newSelectorTestPlan(t)
+
+	err := plan.Select(parseTestSelectors(t, "gvk:apps/v1/DaemonSet"), nil)
+	require.EqualError(t, err, "selector 'gvk:apps/v1/DaemonSet' doesn't match any definition")
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, value := range []string{
+		"apps/v1",
+		"package:",
+		"package:[",
+		"gv:apps",
+		"gv:/v1",
+		"gvk:apps/v1",
+		"gvk:apps/v1/",
+	} {
+		_, err := ParseSelector(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -1,13 +1,17 @@
 package split
 
 import (
-	"fmt"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 
 	openapi_spec "github.com/go-openapi/spec"
 	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/kubewarden/k8s-objects-generator/emitter"
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
 )
 
 // Splitter takes care of splitting the single big swagger file of Kubernetes
@@ -32,34 +36,74 @@ func NewSplitter(swaggerFile string) (Splitter, error) {
 	}, nil
 }
 
-func (s *Splitter) ComputeRefactoringPlan() (*RefactoringPlan, error) {
-	return NewRefactoringPlan(&s.vanillaSwagger)
+func (s *Splitter) ComputeRefactoringPlan(mapping *swaggerhelpers.PackageMapping, patches []swaggerhelpers.DefinitionPatch) (*RefactoringPlan, error) {
+	return NewRefactoringPlan(&s.vanillaSwagger, mapping, patches)
 }
 
-func (s *Splitter) GenerateSwaggerFiles(project Project, plan *RefactoringPlan) error {
-	swaggerFiles, err := plan.RenderNewSwaggerFiles(project.GitRepo)
+// GenerateSwaggerFiles writes the swagger file and the models of each
+// package. Up to `jobs` packages are generated concurrently, a package is
+// generated only once all its dependencies have been generated.
+//
+// The packages that are found inside of the cache are restored from there.
+// The cache can be nil, in which case all the packages are generated.
+func (s *Splitter) GenerateSwaggerFiles(project Project, plan *RefactoringPlan, jobs int, cache *ModelCache, options emitter.Options) error {
+	swaggers, err := plan.RenderNewSwaggers(project.GitRepo)
 	if err != nil {
 		return err
 	}
 
-	for pkgName, jsonData := range swaggerFiles {
-		slog.Info("Generating models for package", "package", pkgName)
+	dependenciesGraph, err := plan.DependenciesGraph()
+	if err != nil {
+		return errors.Wrap(err, "cannot compute dependencies between packages")
+	}
 
-		pathToSwagger := filepath.Join(project.OutputDir,
-			"src",
-			project.GitRepo,
-			pkgName)
-		if err := os.MkdirAll(pathToSwagger, 0o750); err != nil {
-			return errors.Wrapf(err, "cannot create directory %s", pathToSwagger)
-		}
+	modelEmitter := emitter.NewEmitter(afero.NewOsFs(), options)
+	scheduler := newPackageScheduler(dependenciesGraph, jobs)
+
+	return scheduler.Run(func(pkgName string) error {
+		return generatePackage(project, modelEmitter, cache, pkgName, swaggers[pkgName])
+	})
+}
+
+func generatePackage(project Project, modelEmitter *emitter.Emitter, cache *ModelCache, pkgName string, swagger openapi_spec.Swagger) error {
+	pathToSwagger := filepath.Join(project.Root, pkgName)
+	if err := os.MkdirAll(pathToSwagger, 0o750); err != nil {
+		return errors.Wrapf(err, "cannot create directory %s", pathToSwagger)
+	}
+
+	jsonData, err := swagger.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "cannot render swagger file for package %s to JSON", pkgName)
+	}
 
-		fileName := filepath.Join(pathToSwagger, "swagger.json")
-		if err := os.WriteFile(fileName, []byte(jsonData), 0o600); err != nil {
-			return errors.Wrapf(err, "cannot write %s", fileName)
+	var cacheKey string
+	if cache != nil {
+		cacheKey = cache.Key(modelEmitter.Fingerprint(), pkgName, jsonData)
+		restored, err := cache.Restore(cacheKey, pathToSwagger)
+		if err != nil {
+			return errors.Wrapf(err, "cannot restore models of package %s from cache", pkgName)
+		}
+		if restored {
+			slog.Info("Restored models of package from cache", "package", pkgName)
+			return nil
 		}
+	}
+
+	slog.Info("Generating models for package", "package", pkgName)
+
+	fileName := filepath.Join(pathToSwagger, "swagger.json")
+	if err := os.WriteFile(fileName, jsonData, 0o600); err != nil {
+		return errors.Wrapf(err, "cannot write %s", fileName)
+	}
+
+	if err := modelEmitter.Generate(pathToSwagger, path.Base(pkgName), swagger.Definitions); err != nil {
+		return errors.Wrapf(err, "cannot generate models for package %s", pkgName)
+	}
 
-		if err := project.InvokeSwaggerModelGenerator(pkgName); err != nil {
-			return fmt.Errorf("swagger execution failed for module %s: %+w", pkgName, err)
+	if cache != nil {
+		if err := cache.Store(cacheKey, pathToSwagger); err != nil {
+			// the next run will generate the package again
+			slog.Warn("Cannot cache the models of package", "package", pkgName, "error", err)
 		}
 	}
 
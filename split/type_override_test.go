@@ -0,0 +1,86 @@
+package split
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+func TestOverrideTypes(t *testing.T) {
+	splitter, err := NewSplitter(filepath.Join("testdata", "test-swagger.json"))
+	require.NoError(t, err)
+	plan, err := splitter.ComputeRefactoringPlan(swaggerhelpers.DefaultPackageMapping(), nil)
+	require.NoError(t, err)
+
+	plan.OverrideTypes([]swaggerhelpers.TypeOverride{
+		{Definition: "io.k8s.api.events.v1.EventSeries", Package: "example.com/events", Type: "Series"},
+		{Definition: "io.k8s.api.events.v1.Event", Package: "example.com/events", Alias: "ev", Type: "Event"},
+	})
+
+	swaggers, err := plan.RenderNewSwaggers("github.com/kubewarden/k8s-objects")
+	require.NoError(t, err)
+	definitions := swaggers["api/events/v1"].Definitions
+
+	// the overridden definitions are provided by the other package
+	assert.Equal(t, map[string]interface{}{
+		"import": map[string]string{"package": "example.com/events", "alias": ""},
+		"type":   "Series",
+	}, definitions["EventSeries"].Extensions["x-go-type"])
+	assert.Equal(t, map[string]interface{}{
+		"import": map[string]string{"package": "example.com/events", "alias": "ev"},
+		"type":   "Event",
+	}, definitions["Event"].Extensions["x-go-type"])
+
+	report := plan.Report()
+	require.Len(t, report.Packages, 1)
+	assert.Equal(t, []TypeReport{
+		{Name: "Event", ID: "io.k8s.api.events.v1.Event", GoType: "example.com/events.Event"},
+		{Name: "EventSeries", ID: "io.k8s.api.events.v1.EventSeries", GoType: "example.com/events.Series"},
+	}, report.Packages[0].Types)
+	// the kinds are implemented only by generated types
+	assert.Empty(t, report.Packages[0].GVKs)
+}
+
+func TestOverrideTypesReferences(t *testing.T) {
+	splitter, err := NewSplitter(filepath.Join("testdata", "test-swagger.json"))
+	require.NoError(t, err)
+	plan, err := splitter.ComputeRefactoringPlan(swaggerhelpers.DefaultPackageMapping(), nil)
+	require.NoError(t, err)
+
+	plan.OverrideTypes([]swaggerhelpers.TypeOverride{
+		{Definition: "io.k8s.api.events.v1.EventSeries", Package: "example.com/events", Type: "Series"},
+		{Definition: "io.k8s.apimachinery.pkg.apis.meta.v1.MicroTime", Package: "time", Type: "Time"},
+	})
+
+	swaggers, err := plan.RenderNewSwaggers("github.com/kubewarden/k8s-objects")
+	require.NoError(t, err)
+	event := swaggers["api/events/v1"].Definitions["Event"]
+
+	// both the references to the same package and to other packages are
+	// replaced
+	series := event.Properties["series"]
+	assert.Empty(t, series.Ref.String())
+	assert.Equal(t, map[string]interface{}{
+		"import": map[string]string{"package": "example.com/events", "alias": ""},
+		"type":   "Series",
+	}, series.Extensions["x-go-type"])
+	// like the objects they replace, the existing types are referenced by
+	// pointer by the optional properties
+	assert.Equal(t, true, series.Extensions["x-nullable"])
+	assert.Equal(t, true, series.Extensions["x-omitempty"])
+
+	// MicroTime is not part of the input, the references to it are replaced
+	// anyway
+	eventTime := event.Properties["eventTime"]
+	assert.Equal(t, map[string]interface{}{
+		"import": map[string]string{"package": "time", "alias": ""},
+		"type":   "Time",
+	}, eventTime.Extensions["x-go-type"])
+	// eventTime is required
+	assert.NotContains(t, eventTime.Extensions, "x-nullable")
+	assert.NotContains(t, eventTime.Extensions, "x-omitempty")
+}
@@ -0,0 +1,182 @@
+package split
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VerifyProblem is a problem reported by the go commands about a generated
+// file.
+type VerifyProblem struct {
+	// Position of the problem, like `api/core/v1/pod.go:12:3`, relative to
+	// the root of the project
+	Position string
+	Message  string
+	// Definition is the id of the definition the failing code has been
+	// generated from, empty when it cannot be found
+	Definition string
+}
+
+func (p VerifyProblem) String() string {
+	if p.Definition == "" {
+		return p.Position + ": " + p.Message
+	}
+	return fmt.Sprintf("%s: %s (definition %s)", p.Position, p.Message, p.Definition)
+}
+
+// VerifyError is returned when the generated packages don't build, or don't
+// pass `go vet`.
+type VerifyError struct {
+	// Command is the failed go command, like `go vet`
+	Command  string
+	Problems []VerifyProblem
+	// Output of the command, set when it doesn't report any problem about
+	// a file
+	Output string
+}
+
+func (e *VerifyError) Error() string {
+	if len(e.Problems) == 0 {
+		return fmt.Sprintf("%s failed on the generated packages:\n%s", e.Command, e.Output)
+	}
+
+	problems := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		problems = append(problems, problem.String())
+	}
+	return fmt.Sprintf("%s failed on the generated packages:\n  - %s", e.Command, strings.Join(problems, "\n  - "))
+}
+
+// Verify builds and vets all the packages of the project. The problems are
+// mapped to the definitions of the plan the failing code has been generated
+// from. The project must be tidy, and it cannot be committed when the
+// verification fails.
+func (p *Project) Verify(plan *RefactoringPlan) error {
+	p.verifyError = p.verify(plan)
+	return p.verifyError
+}
+
+func (p *Project) verify(plan *RefactoringPlan) error {
+	for _, command := range []string{"build", "vet"} {
+		output, err := p.runGoOutput([]string{command, "./..."})
+		if err == nil {
+			continue
+		}
+
+		verifyError := &VerifyError{
+			Command:  "go " + command,
+			Problems: parseVerifyProblems(p.Root, output, plan),
+		}
+		if len(verifyError.Problems) == 0 {
+			verifyError.Output = strings.TrimSpace(string(output)) + "\n" + err.Error()
+		}
+		return verifyError
+	}
+	return nil
+}
+
+// verifyProblemRegexp matches the problems reported by the go commands, like
+// `api/core/v1/pod.go:12:3: undefined: Foo`. The type checking errors of
+// `go vet` are prefixed by `vet: `.
+var verifyProblemRegexp = regexp.MustCompile(`^(?:vet: )?(?:\./)?(\S+\.go):(\d+)(?::(\d+))?: (.+)$`) //nolint:gochecknoglobals // compiled once
+
+func parseVerifyProblems(root string, output []byte, plan *RefactoringPlan) []VerifyProblem {
+	definitions := definitionIDs(plan)
+	problems := []VerifyProblem{}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		match := verifyProblemRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		file, message := match[1], match[4]
+		lineNumber, _ := strconv.Atoi(match[2])
+
+		position := file + ":" + match[2]
+		if match[3] != "" {
+			position += ":" + match[3]
+		}
+
+		problem := VerifyProblem{Position: position, Message: message}
+		if typeName := enclosingTypeName(filepath.Join(root, file), lineNumber); typeName != "" {
+			problem.Definition = definitions[filepath.ToSlash(filepath.Dir(file))][typeName]
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// definitionIDs indexes the ids of the definitions by package and type name.
+func definitionIDs(plan *RefactoringPlan) map[string]map[string]string {
+	definitions := make(map[string]map[string]string, len(plan.Packages))
+	for pkgName, pkg := range plan.Packages {
+		types := make(map[string]string, len(pkg.Definitions))
+		for _, dfn := range pkg.Definitions {
+			types[dfn.TypeName] = dfn.ID
+		}
+		definitions[pkgName] = types
+	}
+	return definitions
+}
+
+// enclosingTypeName returns the name of the type whose declaration, or
+// method, contains the line of the file. The first type declared by the file
+// is returned when the line is outside of them, like for the imports.
+func enclosingTypeName(fileName string, line int) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return ""
+	}
+
+	contains := func(node ast.Node) bool {
+		return fset.Position(node.Pos()).Line <= line && line <= fset.Position(node.End()).Line
+	}
+
+	firstType := ""
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if contains(typeSpec) {
+					return typeSpec.Name.Name
+				}
+				if firstType == "" {
+					firstType = typeSpec.Name.Name
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 && contains(decl) {
+				if name := receiverTypeName(decl.Recv.List[0].Type); name != "" {
+					return name
+				}
+			}
+		}
+	}
+
+	return firstType
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	default:
+		return ""
+	}
+}
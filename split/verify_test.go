@@ -0,0 +1,109 @@
+package split
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewarden/k8s-objects-generator/swaggerhelpers"
+)
+
+const podSource = `package v1
+
+import (
+	"encoding/json"
+)
+
+type Pod struct {
+	Spec *PodSpec ` + "`json:\"spec\"`" + `
+}
+
+func (m *Pod) Validate() error {
+	return undefined
+}
+`
+
+func TestParseVerifyProblems(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "api", "core", "v1"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api", "core", "v1", "pod.go"), []byte(podSource), 0o600))
+
+	pkg := swaggerhelpers.NewPackage("api/core/v1")
+	pkg.Definitions = append(pkg.Definitions, &swaggerhelpers.Definition{ID: "io.k8s.api.core.v1.Pod", PackageName: "api/core/v1", TypeName: "Pod"})
+	plan := &RefactoringPlan{Packages: map[string]swaggerhelpers.Package{"api/core/v1": pkg}}
+
+	output := `# example.com/objects/api/core/v1
+api/core/v1/pod.go:4:2: "encoding/json" imported and not used
+api/core/v1/pod.go:8:8: undefined: PodSpec
+api/core/v1/pod.go:12:9: undefined: undefined
+vet: ./api/core/v1/pod.go:8:8: undefined: PodSpec
+apimachinery/pkg/runtime/schema/interfaces.go:3:1: expected 'package', found 'EOF'
+`
+	problems := parseVerifyProblems(root, []byte(output), plan)
+	assert.Equal(t, []VerifyProblem{
+		{Position: "api/core/v1/pod.go:4:2", Message: `"encoding/json" imported and not used`, Definition: "io.k8s.api.core.v1.Pod"},
+		{Position: "api/core/v1/pod.go:8:8", Message: "undefined: PodSpec", Definition: "io.k8s.api.core.v1.Pod"},
+		{Position: "api/core/v1/pod.go:12:9", Message: "undefined: undefined", Definition: "io.k8s.api.core.v1.Pod"},
+		{Position: "api/core/v1/pod.go:8:8", Message: "undefined: PodSpec", Definition: "io.k8s.api.core.v1.Pod"},
+		{Position: "apimachinery/pkg/runtime/schema/interfaces.go:3:1", Message: "expected 'package', found 'EOF'"},
+	}, problems)
+
+	err := &VerifyError{Command: "go build", Problems: problems[1:2]}
+	assert.EqualError(t, err, "go build failed on the generated packages:\n"+
+		"  - api/core/v1/pod.go:8:8: undefined: PodSpec (definition io.k8s.api.core.v1.Pod)")
+}
+
+func TestProjectVerify(t *testing.T) {
+	outputDir := t.TempDir()
+	project, err := NewProject(outputDir, "example.com/objects", LayoutModule)
+	require.NoError(t, err)
+	require.NoError(t, project.Init([]byte("{}"), "1.32.0", "license"))
+	require.NoError(t, project.Commit())
+
+	pkg := swaggerhelpers.NewPackage("api/core/v1")
+	pkg.Definitions = append(pkg.Definitions, &swaggerhelpers.Definition{ID: "io.k8s.api.core.v1.Pod", PackageName: "api/core/v1", TypeName: "Pod"})
+	plan := &RefactoringPlan{Packages: map[string]swaggerhelpers.Package{"api/core/v1": pkg}}
+
+	// the generated packages don't build
+	require.NoError(t, project.Init([]byte("{}"), "1.33.0", "license"))
+	require.NoError(t, os.MkdirAll(filepath.Join(project.Root, "api", "core", "v1"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(project.Root, "api", "core", "v1", "pod.go"), []byte(podSource), 0o600))
+
+	err = project.Verify(plan)
+	var verifyError *VerifyError
+	require.ErrorAs(t, err, &verifyError)
+	assert.Equal(t, "go build", verifyError.Command)
+	require.NotEmpty(t, verifyError.Problems)
+	for _, problem := range verifyError.Problems {
+		assert.Equal(t, "io.k8s.api.core.v1.Pod", problem.Definition, problem.String())
+	}
+
+	// the previous output is not replaced
+	require.ErrorAs(t, project.Commit(), &verifyError)
+	assert.DirExists(t, project.Root)
+	assert.NotEqual(t, project.Target, project.Root)
+	version, err := os.ReadFile(filepath.Join(project.Target, "KUBERNETES_VERSION"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.32.0", string(version))
+
+	// the generated packages build, but don't pass go vet
+	vetSource := "package v1\n\nimport \"fmt\"\n\ntype Pod struct{}\n\nfunc (m *Pod) String() string {\n\treturn fmt.Sprintf(\"%d\", \"pod\")\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(project.Root, "api", "core", "v1", "pod.go"), []byte(vetSource), 0o600))
+	require.ErrorAs(t, project.Verify(plan), &verifyError)
+	assert.Equal(t, "go vet", verifyError.Command)
+	require.Len(t, verifyError.Problems, 1)
+	assert.Equal(t, "api/core/v1/pod.go:8:22", verifyError.Problems[0].Position)
+	assert.Equal(t, "io.k8s.api.core.v1.Pod", verifyError.Problems[0].Definition)
+	require.ErrorAs(t, project.Commit(), &verifyError)
+
+	// the fixed packages are committed
+	require.NoError(t, os.WriteFile(filepath.Join(project.Root, "api", "core", "v1", "pod.go"), []byte("package v1\n\ntype Pod struct{}\n"), 0o600))
+	require.NoError(t, project.Verify(plan))
+	require.NoError(t, project.Commit())
+	version, err = os.ReadFile(filepath.Join(project.Target, "KUBERNETES_VERSION"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.33.0", string(version))
+}
@@ -2,17 +2,18 @@ package swaggerhelpers
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	openapi_spec "github.com/go-openapi/spec"
 	"github.com/pkg/errors"
-
-	"github.com/kubewarden/k8s-objects-generator/common"
 )
 
 // Definition is wrapper around a Swagger Definition.
 type Definition struct {
+	// Id of the definition inside of the original swagger file
+	ID string
 	// Original definition
 	SwaggerDefinition openapi_spec.Schema
 	// Name of the package where the object declared by this Definition is going
@@ -28,26 +29,31 @@ type Definition struct {
 	// `apimachinery/pkg/apis/meta/v1/ObjectMeta`, then this definition depends
 	// on `apimachinery/pkg/apis/meta/v1/`
 	dependencies mapset.Set[string]
-}
 
-func NewDefinition(definition openapi_spec.Schema, id string) (*Definition, error) {
-	// Some definitions need special tuning to work properly
-	patchDefinition(&definition, id)
+	// ids of the definitions referenced by this Definition
+	references mapset.Set[string]
+
+	// mapping used to resolve the packages of the definition and of the
+	// definitions it references
+	packageMapping *PackageMapping
+}
 
-	path := strings.TrimPrefix(id, "io.k8s.")
-	chunks := strings.Split(path, ".")
-	if len(chunks) < common.ChunkNumber {
-		return nil,
-			fmt.Errorf("cannot build definition refactoring plan: wrong number of chunks: %v", chunks)
+// NewDefinition returns the refactoring plan of the definition, its package
+// is resolved using the given mapping.
+func NewDefinition(definition openapi_spec.Schema, id string, mapping *PackageMapping) (*Definition, error) {
+	resolved, err := mapping.Resolve(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot build definition refactoring plan")
 	}
 
-	packageName := strings.Join(chunks[0:len(chunks)-1], "/")
-	typeName := chunks[len(chunks)-1]
 	plan := Definition{
+		ID:                id,
 		SwaggerDefinition: definition,
-		PackageName:       packageName,
-		TypeName:          typeName,
+		PackageName:       resolved.PackageName,
+		TypeName:          resolved.TypeName,
 		dependencies:      mapset.NewSet[string](),
+		references:        mapset.NewSet[string](),
+		packageMapping:    mapping,
 	}
 
 	if err := plan.computeDependencies(); err != nil {
@@ -57,21 +63,12 @@ func NewDefinition(definition openapi_spec.Schema, id string) (*Definition, erro
 	return &plan, nil
 }
 
-func patchDefinition(definition *openapi_spec.Schema, id string) {
-	// Ensure `Time` objects consumed by structs are accessed via pointers. This ensures they can be
-	// completely omitted when they are not set.
-	// This fixes https://github.com/kubewarden/kubewarden-controller/issues/570
-	if id == "io.k8s.apimachinery.pkg.apis.meta.v1.Time" {
-		definition.AddExtension("x-nullable", true)
-	}
-}
-
 //nolint:gocognit // keep cognitive complexity as it is, this function is quite contained
 func (d *Definition) computeDependencies() error {
 	var propImports []PropertyImport
 
 	for name, property := range d.SwaggerDefinition.Properties {
-		propImport, err := NewPropertyImportFromRef(&property.Ref)
+		propImport, err := NewPropertyImportFromRef(&property.Ref, d.packageMapping)
 		if err != nil {
 			return errors.Wrapf(err,
 				"cannot parse ref pointer for property %s inside of %s/%s",
@@ -80,9 +77,10 @@ func (d *Definition) computeDependencies() error {
 		if !propImport.IsEmpty() {
 			propImports = append(propImports, propImport)
 		}
+		d.addReference(&property.Ref)
 
 		if property.Items != nil && property.Items.Schema != nil {
-			propImport, err := NewPropertyImportFromRef(&property.Items.Schema.Ref)
+			propImport, err := NewPropertyImportFromRef(&property.Items.Schema.Ref, d.packageMapping)
 			if err != nil {
 				return errors.Wrapf(err,
 					"cannot parse ref pointer for property item %s inside of %s/%s",
@@ -91,10 +89,11 @@ func (d *Definition) computeDependencies() error {
 			if !propImport.IsEmpty() {
 				propImports = append(propImports, propImport)
 			}
+			d.addReference(&property.Items.Schema.Ref)
 		}
 
 		if property.AdditionalProperties != nil {
-			propImport, err := NewPropertyImportFromRef(&property.AdditionalProperties.Schema.Ref)
+			propImport, err := NewPropertyImportFromRef(&property.AdditionalProperties.Schema.Ref, d.packageMapping)
 			if err != nil {
 				return errors.Wrapf(err,
 					"cannot parse ref pointer for additional property %s inside of %s/%s",
@@ -103,6 +102,7 @@ func (d *Definition) computeDependencies() error {
 			if !propImport.IsEmpty() {
 				propImports = append(propImports, propImport)
 			}
+			d.addReference(&property.AdditionalProperties.Schema.Ref)
 		}
 	}
 
@@ -115,9 +115,41 @@ func (d *Definition) computeDependencies() error {
 	return nil
 }
 
-func (d *Definition) GeneratePatchedOpenAPIDef(gitRepo string, interfaces *InterfaceRegistry) (openapi_spec.Schema, error) {
+// addReference keeps track of the definition referenced by `ref`, if any.
+func (d *Definition) addReference(ref *openapi_spec.Ref) {
+	if id := refDefinitionID(ref); id != "" {
+		d.references.Add(id)
+	}
+}
+
+// refDefinitionID returns the id of the definition referenced by `ref`, empty
+// when `ref` is not set.
+func refDefinitionID(ref *openapi_spec.Ref) string {
+	refPointer := ref.GetPointer()
+	if refPointer == nil || refPointer.IsEmpty() {
+		return ""
+	}
+	return strings.TrimPrefix(refPointer.String(), "/definitions/")
+}
+
+// References returns the sorted ids of the definitions referenced by the
+// properties of this Definition.
+func (d *Definition) References() []string {
+	references := d.references.ToSlice()
+	slices.Sort(references)
+	return references
+}
+
+func (d *Definition) GeneratePatchedOpenAPIDef(gitRepo string, interfaces *InterfaceRegistry, overrides TypeOverrides) (openapi_spec.Schema, error) {
 	definition := d.SwaggerDefinition
 
+	if override, found := overrides.Lookup(d.ID); found {
+		// The type is provided by another package, the definition is not
+		// generated
+		definition.AddExtension("x-go-type", override.toMap())
+		return definition, nil
+	}
+
 	if interfaces.IsInterface(gitRepo, d.PackageName, d.TypeName) {
 		// This is an interface, we have to generate not an `{}interface` but
 		// a `json.RawMessage`. Interfaces cannot be handled neither by TinyGo,
@@ -144,18 +176,18 @@ func (d *Definition) GeneratePatchedOpenAPIDef(gitRepo string, interfaces *Inter
 		property := definition.Properties[name]
 		isRequired := required.Contains(name)
 
-		if err := patchSchemaRef(&property, d.PackageName, interfaces, isRequired, gitRepo); err != nil {
+		if err := patchSchemaRef(&property, d.PackageName, d.packageMapping, interfaces, overrides, isRequired, gitRepo); err != nil {
 			return openapi_spec.Schema{}, err
 		}
 
 		if property.Items != nil && property.Items.Schema != nil {
-			if err := patchSchemaRef(property.Items.Schema, d.PackageName, interfaces, isRequired, gitRepo); err != nil {
+			if err := patchSchemaRef(property.Items.Schema, d.PackageName, d.packageMapping, interfaces, overrides, isRequired, gitRepo); err != nil {
 				return openapi_spec.Schema{}, err
 			}
 		}
 
 		if property.AdditionalProperties != nil {
-			if err := patchSchemaRef(property.AdditionalProperties.Schema, d.PackageName, interfaces, isRequired, gitRepo); err != nil {
+			if err := patchSchemaRef(property.AdditionalProperties.Schema, d.PackageName, d.packageMapping, interfaces, overrides, isRequired, gitRepo); err != nil {
 				return openapi_spec.Schema{}, err
 			}
 		}
@@ -167,18 +199,33 @@ func (d *Definition) GeneratePatchedOpenAPIDef(gitRepo string, interfaces *Inter
 }
 
 // patchSchemaRef changes the Ref value of the provided schema object to replace all
-// references with x-go-import statements.
+// references with x-go-import statements. The references to the overridden
+// definitions are replaced by their Go type.
 func patchSchemaRef(schema *openapi_spec.Schema,
 	definitionPackage string,
+	mapping *PackageMapping,
 	interfaces *InterfaceRegistry,
+	overrides TypeOverrides,
 	isRequired bool,
 	gitRepo string,
 ) error {
-	propImport, err := NewPropertyImportFromRef(&schema.Ref)
+	propImport, err := NewPropertyImportFromRef(&schema.Ref, mapping)
 	if err != nil {
 		return err
 	}
 
+	if override, found := overrides.Lookup(refDefinitionID(&schema.Ref)); found {
+		// The existing type replaces an object: like the objects, it's
+		// referenced by pointer when the property is not required
+		schema.Ref = openapi_spec.Ref{}
+		schema.AddExtension("x-go-type", override.toMap())
+		if !isRequired {
+			schema.AddExtension("x-omitempty", true)
+			schema.AddExtension("x-nullable", true)
+		}
+		return nil
+	}
+
 	// handle non-required attributes
 	isInterface := false
 	if !propImport.IsEmpty() {
@@ -0,0 +1,397 @@
+package swaggerhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/pkg/errors"
+)
+
+const (
+	xNullable  = "x-nullable"
+	xOmitEmpty = "x-omitempty"
+)
+
+// DefinitionPatch is a rule tuning the definitions before they are split. The
+// rule matches schemas, either definitions or schemas nested inside of them,
+// and applies its operations to them.
+type DefinitionPatch struct {
+	// Name identifies the rule inside of the report
+	Name string `yaml:"name" json:"name,omitempty"`
+
+	// Definition is the id of the patched definitions, `*` matches any
+	// sequence of characters. All the definitions are matched when empty
+	Definition string `yaml:"definition" json:"definition,omitempty"`
+	// Path selects the schemas nested inside of the definition, like
+	// `$.properties.spec.properties.*.items`. The definition itself is
+	// selected when empty
+	Path string `yaml:"path" json:"path,omitempty"`
+	// Where filters the selected schemas
+	Where *SchemaPredicate `yaml:"where" json:"where,omitempty"`
+
+	// Schema replaces the selected schemas, before the other operations are
+	// applied
+	Schema map[string]interface{} `yaml:"schema" json:"schema,omitempty"`
+	// Extensions are added to the schemas, replacing the existing ones with
+	// the same name
+	Extensions map[string]interface{} `yaml:"extensions" json:"extensions,omitempty"`
+	// RemoveExtensions are the names of the extensions removed from the
+	// schemas
+	RemoveExtensions []string `yaml:"removeExtensions" json:"removeExtensions,omitempty"`
+	// Nullable sets the `x-nullable` extension: nullable properties are
+	// pointers
+	Nullable *bool `yaml:"nullable" json:"nullable,omitempty"`
+	// OmitEmpty sets the `x-omitempty` extension
+	OmitEmpty *bool `yaml:"omitEmpty" json:"omitEmpty,omitempty"`
+	// Required marks the selected properties as required, or optional when
+	// false. The path must select properties
+	Required *bool `yaml:"required" json:"required,omitempty"`
+}
+
+// SchemaPredicate matches schemas by their content, all the fields that are
+// set must match.
+type SchemaPredicate struct {
+	// Type of the schema. Schemas without type, which are not references,
+	// are objects
+	Type string `yaml:"type" json:"type,omitempty"`
+	// Format of the schema, like `date-time`
+	Format string `yaml:"format" json:"format,omitempty"`
+	// HasProperties matches the objects with, or without, properties
+	HasProperties *bool `yaml:"hasProperties" json:"hasProperties,omitempty"`
+	// Ref is the id of the definition referenced by the schema
+	Ref string `yaml:"ref" json:"ref,omitempty"`
+	// HasExtension matches the schemas declaring the extension
+	HasExtension string `yaml:"hasExtension" json:"hasExtension,omitempty"`
+}
+
+// PatchMatch records a schema patched by a rule.
+type PatchMatch struct {
+	Rule       string `json:"rule"`
+	Definition string `json:"definition"`
+	// Location of the schema inside of the definition, as a JSON path
+	Location string `json:"location"`
+
+	ruleIndex int
+}
+
+// DefaultDefinitionPatches returns the patches that are always applied, before
+// the configured ones.
+func DefaultDefinitionPatches() []DefinitionPatch {
+	nullable := true
+	return []DefinitionPatch{
+		{
+			// Ensure `Time` objects consumed by structs are accessed via pointers. This ensures they can be
+			// completely omitted when they are not set.
+			// This fixes https://github.com/kubewarden/kubewarden-controller/issues/570
+			Name:       "nullable-time",
+			Definition: "io.k8s.apimachinery.pkg.apis.meta.v1.Time",
+			Nullable:   &nullable,
+		},
+	}
+}
+
+// Validate checks the patch is well formed.
+func (p *DefinitionPatch) Validate() error {
+	_, err := compilePatch(p)
+	return err
+}
+
+// pathStep is an element of the path of a patch: `properties.<name>`,
+// `items` or `additionalProperties`.
+type pathStep struct {
+	property string
+	items    bool
+}
+
+type compiledPatch struct {
+	DefinitionPatch
+	steps  []pathStep
+	schema []byte
+}
+
+func compilePatch(p *DefinitionPatch) (*compiledPatch, error) {
+	if p.Definition == "" && p.Path == "" && p.Where == nil {
+		return nil, errors.New("at least one of definition, path and where must be set")
+	}
+	if _, err := path.Match(p.Definition, ""); err != nil {
+		return nil, errors.Wrapf(err, "invalid definition pattern '%s'", p.Definition)
+	}
+	if len(p.Schema) == 0 && len(p.Extensions) == 0 && len(p.RemoveExtensions) == 0 &&
+		p.Nullable == nil && p.OmitEmpty == nil && p.Required == nil {
+		return nil, errors.New("at least one operation must be set")
+	}
+	for name := range p.Extensions {
+		if !isExtension(name) {
+			return nil, fmt.Errorf("invalid extension '%s': the name must start with 'x-'", name)
+		}
+	}
+	for _, name := range p.RemoveExtensions {
+		if !isExtension(name) {
+			return nil, fmt.Errorf("invalid extension '%s': the name must start with 'x-'", name)
+		}
+	}
+
+	compiled := compiledPatch{DefinitionPatch: *p}
+
+	steps, err := parsePatchPath(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	compiled.steps = steps
+	if p.Required != nil && (len(steps) == 0 || steps[len(steps)-1].property == "") {
+		return nil, fmt.Errorf("required can be used only when the path selects properties, like `$.properties.name`")
+	}
+
+	if len(p.Schema) > 0 {
+		data, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid schema")
+		}
+		schema := openapi_spec.Schema{}
+		if err := schema.UnmarshalJSON(data); err != nil {
+			return nil, errors.Wrap(err, "invalid schema")
+		}
+		compiled.schema = data
+	}
+
+	return &compiled, nil
+}
+
+func isExtension(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "x-")
+}
+
+// parsePatchPath parses paths like `$.properties.spec.items`.
+func parsePatchPath(patchPath string) ([]pathStep, error) {
+	if patchPath == "" || patchPath == "$" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(patchPath, "$.") {
+		return nil, fmt.Errorf("invalid path '%s': it must start with '$.'", patchPath)
+	}
+
+	steps := []pathStep{}
+	elements := strings.Split(strings.TrimPrefix(patchPath, "$."), ".")
+	for i := 0; i < len(elements); i++ {
+		switch elements[i] {
+		case "properties":
+			if i+1 == len(elements) || elements[i+1] == "" {
+				return nil, fmt.Errorf("invalid path '%s': properties must be followed by the name of a property, or by '*'", patchPath)
+			}
+			i++
+			steps = append(steps, pathStep{property: elements[i]})
+		case "items":
+			steps = append(steps, pathStep{items: true})
+		case "additionalProperties":
+			steps = append(steps, pathStep{})
+		default:
+			return nil, fmt.Errorf("invalid path '%s': unexpected '%s', expected one of properties, items and additionalProperties", patchPath, elements[i])
+		}
+	}
+
+	return steps, nil
+}
+
+// Patcher applies the patches to the definitions, recording the schemas each
+// one of them patched.
+type Patcher struct {
+	patches []*compiledPatch
+	matches []PatchMatch
+}
+
+// NewPatcher validates the patches. The patches without a name are named
+// after their position, like `#1`.
+func NewPatcher(patches []DefinitionPatch) (*Patcher, error) {
+	patcher := Patcher{
+		patches: make([]*compiledPatch, 0, len(patches)),
+	}
+
+	for i := range patches {
+		compiled, err := compilePatch(&patches[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid patch #%d", i+1)
+		}
+		if compiled.Name == "" {
+			compiled.Name = fmt.Sprintf("#%d", i+1)
+		}
+		patcher.patches = append(patcher.patches, compiled)
+	}
+
+	return &patcher, nil
+}
+
+// Apply applies the patches to the definition with the given id, in order.
+func (p *Patcher) Apply(id string, definition *openapi_spec.Schema) error {
+	for i, patch := range p.patches {
+		if patch.Definition != "" {
+			if matched, _ := path.Match(patch.Definition, id); !matched {
+				continue
+			}
+		}
+
+		err := patch.walk(definition, nil, "", "$", patch.steps, func(schema, parent *openapi_spec.Schema, property, location string) error {
+			if patch.Where != nil && !patch.Where.matches(schema) {
+				return nil
+			}
+			if err := patch.apply(schema, parent, property); err != nil {
+				return errors.Wrapf(err, "cannot apply patch %s to %s of definition %s", patch.Name, location, id)
+			}
+			p.matches = append(p.matches, PatchMatch{
+				Rule:       patch.Name,
+				Definition: id,
+				Location:   location,
+				ruleIndex:  i,
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Matches returns the schemas patched so far, sorted by patch, definition and
+// location.
+func (p *Patcher) Matches() []PatchMatch {
+	matches := slices.Clone(p.matches)
+	slices.SortFunc(matches, func(a, b PatchMatch) int {
+		if a.ruleIndex != b.ruleIndex {
+			return a.ruleIndex - b.ruleIndex
+		}
+		if a.Definition != b.Definition {
+			return strings.Compare(a.Definition, b.Definition)
+		}
+		return strings.Compare(a.Location, b.Location)
+	})
+	return matches
+}
+
+// Unused returns the names of the patches that didn't patch any schema.
+func (p *Patcher) Unused() []string {
+	used := make([]bool, len(p.patches))
+	for _, match := range p.matches {
+		used[match.ruleIndex] = true
+	}
+
+	unused := []string{}
+	for i, patch := range p.patches {
+		if !used[i] {
+			unused = append(unused, patch.Name)
+		}
+	}
+	return unused
+}
+
+type visitFunc func(schema, parent *openapi_spec.Schema, property, location string) error
+
+// walk visits the schemas selected by the steps. The parent and the property
+// are set when the visited schema is a property.
+func (p *compiledPatch) walk(schema, parent *openapi_spec.Schema, property, location string, steps []pathStep, visit visitFunc) error {
+	if len(steps) == 0 {
+		return visit(schema, parent, property, location)
+	}
+
+	step := steps[0]
+	switch {
+	case step.property != "":
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			if step.property == "*" || step.property == name {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			propertySchema := schema.Properties[name]
+			if err := p.walk(&propertySchema, schema, name, location+".properties."+name, steps[1:], visit); err != nil {
+				return err
+			}
+			schema.Properties[name] = propertySchema
+		}
+	case step.items:
+		if schema.Items != nil && schema.Items.Schema != nil {
+			return p.walk(schema.Items.Schema, nil, "", location+".items", steps[1:], visit)
+		}
+	default:
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			return p.walk(schema.AdditionalProperties.Schema, nil, "", location+".additionalProperties", steps[1:], visit)
+		}
+	}
+
+	return nil
+}
+
+func (p *compiledPatch) apply(schema, parent *openapi_spec.Schema, property string) error {
+	if p.schema != nil {
+		replacement := openapi_spec.Schema{}
+		if err := replacement.UnmarshalJSON(p.schema); err != nil {
+			return errors.Wrap(err, "invalid schema")
+		}
+		*schema = replacement
+	}
+
+	for name, value := range p.Extensions {
+		schema.AddExtension(name, value)
+	}
+	for _, name := range p.RemoveExtensions {
+		delete(schema.Extensions, strings.ToLower(name))
+	}
+	if p.Nullable != nil {
+		schema.AddExtension(xNullable, *p.Nullable)
+	}
+	if p.OmitEmpty != nil {
+		schema.AddExtension(xOmitEmpty, *p.OmitEmpty)
+	}
+
+	if p.Required != nil {
+		if parent == nil {
+			return errors.New("required can be applied only to properties")
+		}
+		index := slices.Index(parent.Required, property)
+		switch {
+		case *p.Required && index < 0:
+			parent.Required = append(parent.Required, property)
+		case !*p.Required && index >= 0:
+			parent.Required = slices.Delete(parent.Required, index, index+1)
+		}
+	}
+
+	return nil
+}
+
+func (w *SchemaPredicate) matches(schema *openapi_spec.Schema) bool {
+	if w.Type != "" {
+		tpe := ""
+		switch {
+		case len(schema.Type) > 0:
+			tpe = schema.Type[0]
+		case schema.Ref.String() == "":
+			tpe = "object"
+		}
+		if tpe != w.Type {
+			return false
+		}
+	}
+	if w.Format != "" && schema.Format != w.Format {
+		return false
+	}
+	if w.HasProperties != nil && (len(schema.Properties) > 0) != *w.HasProperties {
+		return false
+	}
+	if w.Ref != "" && strings.TrimPrefix(schema.Ref.String(), "#/definitions/") != w.Ref {
+		return false
+	}
+	if w.HasExtension != "" {
+		if _, found := schema.Extensions[strings.ToLower(w.HasExtension)]; !found {
+			return false
+		}
+	}
+	return true
+}
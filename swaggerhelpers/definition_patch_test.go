@@ -0,0 +1,199 @@
+package swaggerhelpers
+
+import (
+	"encoding/json"
+	"testing"
+
+	openapi_spec "github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patchTestDefinitions = `{
+	"io.k8s.api.apps.v1.Deployment": {
+		"type": "object",
+		"required": ["spec"],
+		"properties": {
+			"metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+			"spec": {"type": "object", "properties": {"replicas": {"type": "integer"}}},
+			"status": {"type": "object", "properties": {"conditions": {"type": "array", "items": {"type": "object"}}}}
+		}
+	},
+	"io.k8s.apimachinery.pkg.apis.meta.v1.Time": {"type": "string", "format": "date-time"},
+	"io.k8s.apimachinery.pkg.apis.meta.v1.FieldsV1": {"type": "object"}
+}`
+
+func loadPatchTestDefinitions(t *testing.T) openapi_spec.Definitions {
+	t.Helper()
+
+	definitions := openapi_spec.Definitions{}
+	require.NoError(t, json.Unmarshal([]byte(patchTestDefinitions), &definitions))
+	return definitions
+}
+
+func applyPatches(t *testing.T, definitions openapi_spec.Definitions, patches []DefinitionPatch) *Patcher {
+	t.Helper()
+
+	patcher, err := NewPatcher(patches)
+	require.NoError(t, err)
+	for _, id := range []string{
+		"io.k8s.api.apps.v1.Deployment",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.FieldsV1",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.Time",
+	} {
+		definition := definitions[id]
+		require.NoError(t, patcher.Apply(id, &definition))
+		definitions[id] = definition
+	}
+	return patcher
+}
+
+func TestPatcher(t *testing.T) {
+	enabled, disabled := true, false
+	definitions := loadPatchTestDefinitions(t)
+
+	patcher := applyPatches(t, definitions, []DefinitionPatch{
+		{
+			Name:       "time",
+			Definition: "io.k8s.apimachinery.pkg.apis.meta.v1.Time",
+			Nullable:   &enabled,
+		},
+		{
+			Name:       "apps",
+			Definition: "io.k8s.api.apps.*",
+			Path:       "$.properties.*",
+			Where:      &SchemaPredicate{Type: "object", HasProperties: &enabled},
+			OmitEmpty:  &disabled,
+			Required:   &enabled,
+		},
+		{
+			Name:       "conditions",
+			Path:       "$.properties.status.properties.conditions.items",
+			Extensions: map[string]interface{}{"x-kubernetes-list-type": "map"},
+		},
+		{
+			Name:   "interfaces",
+			Where:  &SchemaPredicate{Type: "object", HasProperties: &disabled},
+			Schema: map[string]interface{}{"type": "string", "x-go-name": "Raw"},
+		},
+		{
+			Name:       "metadata",
+			Path:       "$.properties.metadata",
+			Where:      &SchemaPredicate{Ref: "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+			Extensions: map[string]interface{}{"x-go-name": "Meta"},
+		},
+		{
+			Name:       "unused",
+			Definition: "io.k8s.api.core.*",
+			Nullable:   &enabled,
+		},
+	})
+
+	assert.Equal(t, true, definitions["io.k8s.apimachinery.pkg.apis.meta.v1.Time"].Extensions["x-nullable"])
+
+	deployment := definitions["io.k8s.api.apps.v1.Deployment"]
+	assert.Equal(t, []string{"spec", "status"}, deployment.Required)
+	assert.Equal(t, false, deployment.Properties["spec"].Extensions["x-omitempty"])
+	assert.Equal(t, false, deployment.Properties["status"].Extensions["x-omitempty"])
+	assert.Nil(t, deployment.Properties["metadata"].Extensions["x-omitempty"])
+	assert.Equal(t, "Meta", deployment.Properties["metadata"].Extensions["x-go-name"])
+	assert.Equal(t, "map", deployment.Properties["status"].Properties["conditions"].Items.Schema.Extensions["x-kubernetes-list-type"])
+
+	fieldsV1 := definitions["io.k8s.apimachinery.pkg.apis.meta.v1.FieldsV1"]
+	assert.Equal(t, openapi_spec.StringOrArray{"string"}, fieldsV1.Type)
+	assert.Equal(t, "Raw", fieldsV1.Extensions["x-go-name"])
+
+	assert.Equal(t, []PatchMatch{
+		{Rule: "time", Definition: "io.k8s.apimachinery.pkg.apis.meta.v1.Time", Location: "$", ruleIndex: 0},
+		{Rule: "apps", Definition: "io.k8s.api.apps.v1.Deployment", Location: "$.properties.spec", ruleIndex: 1},
+		{Rule: "apps", Definition: "io.k8s.api.apps.v1.Deployment", Location: "$.properties.status", ruleIndex: 1},
+		{Rule: "conditions", Definition: "io.k8s.api.apps.v1.Deployment", Location: "$.properties.status.properties.conditions.items", ruleIndex: 2},
+		{Rule: "interfaces", Definition: "io.k8s.apimachinery.pkg.apis.meta.v1.FieldsV1", Location: "$", ruleIndex: 3},
+		{Rule: "metadata", Definition: "io.k8s.api.apps.v1.Deployment", Location: "$.properties.metadata", ruleIndex: 4},
+	}, patcher.Matches())
+	assert.Equal(t, []string{"unused"}, patcher.Unused())
+}
+
+func TestPatcherRemoveExtensionAndOptional(t *testing.T) {
+	disabled := false
+	definitions := loadPatchTestDefinitions(t)
+	timeDefinition := definitions["io.k8s.apimachinery.pkg.apis.meta.v1.Time"]
+	timeDefinition.AddExtension("x-nullable", true)
+	definitions["io.k8s.apimachinery.pkg.apis.meta.v1.Time"] = timeDefinition
+
+	patcher := applyPatches(t, definitions, []DefinitionPatch{
+		{
+			Where:            &SchemaPredicate{HasExtension: "X-Nullable"},
+			RemoveExtensions: []string{"x-nullable"},
+		},
+		{
+			Definition: "io.k8s.api.apps.v1.Deployment",
+			Path:       "$.properties.spec",
+			Required:   &disabled,
+		},
+	})
+
+	assert.NotContains(t, definitions["io.k8s.apimachinery.pkg.apis.meta.v1.Time"].Extensions, "x-nullable")
+	assert.Empty(t, definitions["io.k8s.api.apps.v1.Deployment"].Required)
+	// the unnamed patches are named after their position
+	assert.Equal(t, "#1", patcher.Matches()[0].Rule)
+	assert.Equal(t, "#2", patcher.Matches()[1].Rule)
+}
+
+func TestDefinitionPatchValidate(t *testing.T) {
+	enabled := true
+	cases := []struct {
+		name     string
+		patch    DefinitionPatch
+		expected string
+	}{
+		{
+			name:     "no matcher",
+			patch:    DefinitionPatch{Nullable: &enabled},
+			expected: "at least one of definition, path and where must be set",
+		},
+		{
+			name:     "no operation",
+			patch:    DefinitionPatch{Definition: "io.k8s.api.core.v1.Pod"},
+			expected: "at least one operation must be set",
+		},
+		{
+			name:     "invalid extension",
+			patch:    DefinitionPatch{Definition: "io.k8s.api.core.v1.Pod", Extensions: map[string]interface{}{"nullable": true}},
+			expected: "invalid extension 'nullable': the name must start with 'x-'",
+		},
+		{
+			name:     "invalid path",
+			patch:    DefinitionPatch{Path: "properties.spec", Nullable: &enabled},
+			expected: "invalid path 'properties.spec': it must start with '$.'",
+		},
+		{
+			name:     "unknown path element",
+			patch:    DefinitionPatch{Path: "$.spec", Nullable: &enabled},
+			expected: "invalid path '$.spec': unexpected 'spec', expected one of properties, items and additionalProperties",
+		},
+		{
+			name:     "missing property name",
+			patch:    DefinitionPatch{Path: "$.properties", Nullable: &enabled},
+			expected: "invalid path '$.properties': properties must be followed by the name of a property, or by '*'",
+		},
+		{
+			name:     "required without property",
+			patch:    DefinitionPatch{Path: "$.properties.spec.items", Required: &enabled},
+			expected: "required can be used only when the path selects properties, like `$.properties.name`",
+		},
+		{
+			name:     "invalid schema",
+			patch:    DefinitionPatch{Definition: "io.k8s.api.core.v1.Pod", Schema: map[string]interface{}{"type": 1}},
+			expected: "invalid schema",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.patch.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), testCase.expected)
+		})
+	}
+}
@@ -28,7 +28,7 @@ func TestParseDefinitionID(t *testing.T) {
 	emptySchema := openapi_spec.Schema{}
 
 	for _, testCase := range cases {
-		definition, err := NewDefinition(emptySchema, testCase.id)
+		definition, err := NewDefinition(emptySchema, testCase.id, DefaultPackageMapping())
 		if err != nil {
 			t.Errorf("unexpected error while parsing %s: %v", testCase.id, err)
 		}
@@ -104,6 +104,7 @@ func TestComputeDependenciesSimpleSchema(t *testing.T) {
 
 		definition, err := NewDefinition(defSchema,
 			"io.k8s.api.admissionregistration.v1.MutatingWebhook",
+			DefaultPackageMapping(),
 		)
 		if err != nil {
 			t.Errorf("cannot generate definition: %v", err)
@@ -170,6 +171,7 @@ func TestComputeDependenciesSchemaWithAdditionalProperties(t *testing.T) {
 
 		definition, err := NewDefinition(defSchema,
 			"io.k8s.api.admissionregistration.v1.MutatingWebhook",
+			DefaultPackageMapping(),
 		)
 		if err != nil {
 			t.Errorf("cannot generate definition: %v", err)
@@ -236,6 +238,7 @@ func TestComputeDependenciesSchemaWithItems(t *testing.T) {
 
 		definition, err := NewDefinition(defSchema,
 			"io.k8s.api.admissionregistration.v1.MutatingWebhook",
+			DefaultPackageMapping(),
 		)
 		if err != nil {
 			t.Errorf("cannot generate definition: %v", err)
@@ -328,6 +331,7 @@ func TestPatchSchema(t *testing.T) {
 
 	definition, err := NewDefinition(defSchema,
 		"io.k8s.api.admissionregistration.v1.MutatingWebhook",
+		DefaultPackageMapping(),
 	)
 	if err != nil {
 		t.Errorf("cannot generate definition: %v", err)
@@ -335,7 +339,8 @@ func TestPatchSchema(t *testing.T) {
 
 	patchedSchema, err := definition.GeneratePatchedOpenAPIDef(
 		"github.com/kubewarden/k8s-objects",
-		&interfaces)
+		&interfaces,
+		nil)
 	if err != nil {
 		t.Errorf("cannot generate patched schema: %v", err)
 	}
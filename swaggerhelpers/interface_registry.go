@@ -3,6 +3,7 @@ package swaggerhelpers
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -47,6 +48,19 @@ func (r *InterfaceRegistry) IsInterface(gitRepo, module, name string) bool {
 	return interfaces.Contains(name)
 }
 
+// Interfaces returns the sorted names of the interfaces defined inside of the
+// `module` module.
+func (r *InterfaceRegistry) Interfaces(module string) []string {
+	interfaces, known := r.interfacesByModule[module]
+	if !known {
+		return []string{}
+	}
+
+	names := interfaces.ToSlice()
+	slices.Sort(names)
+	return names
+}
+
 func (r *InterfaceRegistry) Dump() {
 	for module, interfaces := range r.interfacesByModule {
 		slog.Info("interfaces for module", "module", module, "interfaces", interfaces)
@@ -30,7 +30,7 @@ func (p *Package) AddDefinitionRefactoringPlan(definition *Definition) {
 	p.Dependencies = p.Dependencies.Union(definition.dependencies)
 }
 
-func (p *Package) GenerateSwagger(swaggerVersion, kubernetesVersion, gitRepo string, interfaces *InterfaceRegistry) (openapi_spec.Swagger, error) {
+func (p *Package) GenerateSwagger(swaggerVersion, kubernetesVersion, gitRepo string, interfaces *InterfaceRegistry, overrides TypeOverrides) (openapi_spec.Swagger, error) {
 	swagger := openapi_spec.Swagger{}
 	swagger.Swagger = swaggerVersion
 
@@ -47,6 +47,7 @@ func (p *Package) GenerateSwagger(swaggerVersion, kubernetesVersion, gitRepo str
 		patchedDefinition, err := def.GeneratePatchedOpenAPIDef(
 			gitRepo,
 			interfaces,
+			overrides,
 		)
 		if err != nil {
 			return openapi_spec.Swagger{},
@@ -0,0 +1,184 @@
+package swaggerhelpers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/kubewarden/k8s-objects-generator/common"
+)
+
+// PackageMappingRule maps the ids of swagger definitions to Go packages.
+//
+// Rules are matched against the namespace of the definition id, which is the
+// id without the type name: `io.k8s.api.core.v1` for the
+// `io.k8s.api.core.v1.Pod` definition.
+type PackageMappingRule struct {
+	// Prefix of the namespaces handled by the rule. The rest of the namespace
+	// is appended to Package, using `/` as separator. For example, the prefix
+	// `com.github.openshift.api.` and the package `openshift` map the
+	// `com.github.openshift.api.route.v1` namespace to `openshift/route/v1`.
+	Prefix string `yaml:"prefix" json:"prefix,omitempty"`
+	// Regexp matching the whole namespace. Package and Alias can reference
+	// its submatches, like `$1` or `${name}`.
+	Regexp string `yaml:"regexp" json:"regexp,omitempty"`
+	// Package is the path of the Go package, relative to the root of the
+	// generated module.
+	Package string `yaml:"package" json:"package,omitempty"`
+	// Alias used when importing the package. When empty, the alias is
+	// derived from the package path: `openshift_route_v1` for
+	// `openshift/route/v1`. When used together with Prefix, the alias
+	// derived from the rest of the namespace is appended to it: the alias
+	// `ocp` gives `ocp_route_v1`.
+	Alias string `yaml:"alias" json:"alias,omitempty"`
+}
+
+// PackageMapping resolves the Go package of the swagger definitions, using
+// the first rule that matches. When no rule matches, the namespace is used
+// as package path: `com.example.v1` -> `com/example/v1`.
+type PackageMapping struct {
+	rules   []PackageMappingRule
+	regexps []*regexp.Regexp
+}
+
+// DefaultPackageMappingRules returns the rules used when none is configured.
+// The `io.k8s.` prefix is dropped from the definitions of Kubernetes:
+// `io.k8s.api.core.v1` -> `api/core/v1`.
+func DefaultPackageMappingRules() []PackageMappingRule {
+	return []PackageMappingRule{
+		{Prefix: "io.k8s."},
+	}
+}
+
+// DefaultPackageMapping returns the mapping built from the default rules.
+func DefaultPackageMapping() *PackageMapping {
+	mapping, err := NewPackageMapping(DefaultPackageMappingRules())
+	if err != nil {
+		panic(err) // the default rules are always valid
+	}
+	return mapping
+}
+
+// NewPackageMapping validates the rules and returns the mapping, the rules are
+// evaluated in the given order.
+func NewPackageMapping(rules []PackageMappingRule) (*PackageMapping, error) {
+	mapping := PackageMapping{
+		rules:   rules,
+		regexps: make([]*regexp.Regexp, len(rules)),
+	}
+
+	for i, rule := range rules {
+		switch {
+		case rule.Prefix != "" && rule.Regexp != "":
+			return nil, fmt.Errorf("package mapping rule #%d: prefix and regexp cannot be used at the same time", i+1)
+		case rule.Prefix == "" && rule.Regexp == "":
+			return nil, fmt.Errorf("package mapping rule #%d: either prefix or regexp must be set", i+1)
+		case rule.Regexp != "":
+			if rule.Package == "" {
+				return nil, fmt.Errorf("package mapping rule #%d: package must be set when using a regexp", i+1)
+			}
+			re, err := regexp.Compile("^(?:" + rule.Regexp + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "package mapping rule #%d: invalid regexp", i+1)
+			}
+			mapping.regexps[i] = re
+		}
+	}
+
+	return &mapping, nil
+}
+
+// LoadPackageMapping reads the rules from a YAML file holding a list of
+// PackageMappingRule. The default rules are appended to the ones defined by
+// the file.
+func LoadPackageMapping(path string) (*PackageMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read package mapping file %s", path)
+	}
+
+	rules := []PackageMappingRule{}
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, errors.Wrapf(err, "cannot decode package mapping file %s", path)
+	}
+
+	mapping, err := NewPackageMapping(append(rules, DefaultPackageMappingRules()...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid package mapping file %s", path)
+	}
+	return mapping, nil
+}
+
+// Rules returns the rules of the mapping.
+func (m *PackageMapping) Rules() []PackageMappingRule {
+	return m.rules
+}
+
+// Resolve returns the package and type of the definition with the given id.
+func (m *PackageMapping) Resolve(id string) (PropertyImport, error) {
+	chunks := strings.Split(id, ".")
+	if len(chunks) < common.ChunkNumber {
+		return PropertyImport{}, fmt.Errorf("not enough chunks for %s: %+v", id, chunks)
+	}
+	namespace := strings.Join(chunks[0:len(chunks)-1], ".")
+	typeName := chunks[len(chunks)-1]
+
+	packageName, alias := m.resolveNamespace(namespace)
+	if packageName == "" {
+		return PropertyImport{}, fmt.Errorf("definition %s is mapped to an empty package", id)
+	}
+	if alias == "" {
+		alias = packageAlias(packageName)
+	}
+
+	return PropertyImport{
+		PackageName: packageName,
+		Alias:       alias,
+		TypeName:    typeName,
+	}, nil
+}
+
+func (m *PackageMapping) resolveNamespace(namespace string) (string, string) {
+	for i, rule := range m.rules {
+		if re := m.regexps[i]; re != nil {
+			if submatches := re.FindStringSubmatchIndex(namespace); submatches != nil {
+				packageName := string(re.ExpandString(nil, rule.Package, namespace, submatches))
+				alias := string(re.ExpandString(nil, rule.Alias, namespace, submatches))
+				return packageName, alias
+			}
+			continue
+		}
+
+		if rest, found := strings.CutPrefix(namespace, rule.Prefix); found {
+			restPath := strings.ReplaceAll(rest, ".", "/")
+			alias := ""
+			if rule.Alias != "" {
+				alias = joinNonEmpty("_", rule.Alias, packageAlias(restPath))
+			}
+			return joinNonEmpty("/", rule.Package, restPath), alias
+		}
+	}
+
+	return strings.ReplaceAll(namespace, ".", "/"), ""
+}
+
+// packageAlias derives the import alias from the package path:
+// `apimachinery/pkg/apis/meta/v1` -> `apimachinery_pkg_apis_meta_v1`.
+func packageAlias(packageName string) string {
+	alias := strings.ReplaceAll(packageName, "/", "_")
+	return strings.ReplaceAll(alias, "-", "")
+}
+
+func joinNonEmpty(separator string, elements ...string) string {
+	nonEmpty := []string{}
+	for _, element := range elements {
+		if element != "" {
+			nonEmpty = append(nonEmpty, element)
+		}
+	}
+	return strings.Join(nonEmpty, separator)
+}
@@ -0,0 +1,130 @@
+package swaggerhelpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	openapi_spec "github.com/go-openapi/spec"
+)
+
+func TestPackageMappingResolve(t *testing.T) {
+	mapping, err := NewPackageMapping(append([]PackageMappingRule{
+		{Prefix: "com.github.openshift.api.", Package: "openshift"},
+		{Prefix: "io.istio.", Package: "istio", Alias: "istio"},
+		{Regexp: `dev\.knative\.(?P<group>[a-z]+)\.(?P<version>v[0-9a-z]+)`, Package: "knative/${group}/${version}", Alias: "kn${group}${version}"},
+	}, DefaultPackageMappingRules()...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		id                  string
+		expectedPackageName string
+		expectedAlias       string
+		expectedTypeName    string
+	}{
+		{
+			id:                  "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta",
+			expectedPackageName: "apimachinery/pkg/apis/meta/v1",
+			expectedAlias:       "apimachinery_pkg_apis_meta_v1",
+			expectedTypeName:    "ObjectMeta",
+		},
+		{
+			id:                  "com.github.openshift.api.route.v1.Route",
+			expectedPackageName: "openshift/route/v1",
+			expectedAlias:       "openshift_route_v1",
+			expectedTypeName:    "Route",
+		},
+		{
+			id:                  "io.istio.networking.v1beta1.VirtualService",
+			expectedPackageName: "istio/networking/v1beta1",
+			expectedAlias:       "istio_networking_v1beta1",
+			expectedTypeName:    "VirtualService",
+		},
+		{
+			id:                  "dev.knative.serving.v1.Service",
+			expectedPackageName: "knative/serving/v1",
+			expectedAlias:       "knservingv1",
+			expectedTypeName:    "Service",
+		},
+		{
+			id:                  "io.cert-manager.v1.Certificate",
+			expectedPackageName: "io/cert-manager/v1",
+			expectedAlias:       "io_certmanager_v1",
+			expectedTypeName:    "Certificate",
+		},
+	}
+
+	for _, testCase := range cases {
+		propImport, err := mapping.Resolve(testCase.id)
+		if err != nil {
+			t.Errorf("unexpected error while resolving %s: %v", testCase.id, err)
+			continue
+		}
+
+		if propImport.PackageName != testCase.expectedPackageName {
+			t.Errorf("%s: expected package name to be %s, got %s instead",
+				testCase.id, testCase.expectedPackageName, propImport.PackageName)
+		}
+		if propImport.Alias != testCase.expectedAlias {
+			t.Errorf("%s: expected alias to be %s, got %s instead",
+				testCase.id, testCase.expectedAlias, propImport.Alias)
+		}
+		if propImport.TypeName != testCase.expectedTypeName {
+			t.Errorf("%s: expected type name to be %s, got %s instead",
+				testCase.id, testCase.expectedTypeName, propImport.TypeName)
+		}
+	}
+}
+
+func TestNewPackageMappingErrors(t *testing.T) {
+	cases := []PackageMappingRule{
+		{},
+		{Prefix: "io.k8s.", Regexp: "io.k8s"},
+		{Regexp: "dev.knative.*"},
+		{Regexp: "(", Package: "knative"},
+	}
+
+	for _, rule := range cases {
+		if _, err := NewPackageMapping([]PackageMappingRule{rule}); err == nil {
+			t.Errorf("expected rule %+v to be rejected", rule)
+		}
+	}
+}
+
+func TestLoadPackageMapping(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.yaml")
+	data := []byte(`- prefix: dev.knative.
+  package: knative
+`)
+	if err := os.WriteFile(mappingFile, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping, err := LoadPackageMapping(mappingFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ref := openapi_spec.MustCreateRef("#/definitions/dev.knative.serving.v1.RouteSpec")
+	defSchema := openapi_spec.Schema{
+		SchemaProps: openapi_spec.SchemaProps{
+			Properties: map[string]openapi_spec.Schema{
+				"spec": {SchemaProps: openapi_spec.SchemaProps{Ref: ref}},
+			},
+		},
+	}
+
+	// rules apply both to the definition and to the definitions it references
+	definition, err := NewDefinition(defSchema, "io.k8s.api.core.v1.Pod", mapping)
+	if err != nil {
+		t.Fatalf("cannot generate definition: %v", err)
+	}
+	if definition.PackageName != "api/core/v1" {
+		t.Errorf("the default rules must be kept, got package %s", definition.PackageName)
+	}
+	if !definition.dependencies.Contains("knative/serving/v1") {
+		t.Errorf("cannot find expected dependency knative/serving/v1 inside of %v", definition.dependencies)
+	}
+}
@@ -1,12 +1,11 @@
 package swaggerhelpers
 
 import (
-	"fmt"
 	"path/filepath"
 	"strings"
 
 	openapi_spec "github.com/go-openapi/spec"
-	"github.com/kubewarden/k8s-objects-generator/common"
+	"github.com/pkg/errors"
 )
 
 type PropertyImport struct {
@@ -36,38 +35,27 @@ func (p *PropertyImport) ToMap(gitRepo string) map[string]interface{} {
 	return outerObj
 }
 
-// NewPropertyImportFromRef returns a propertImport from a Ref.
+// NewPropertyImportFromRef returns a propertImport from a Ref, the package is
+// resolved using the given mapping.
 // Given a `ref` string like:
 // `/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector`
-// return:
+// return, when using the default mapping:
 //
 //	propertyImport"{
 //	   package_name: "apimachinery/pkg/apis/meta/v1",
 //	   alias: "apimachinery_pkgs_apis_meta_v1",
 //	   type_name: "LabelSelector",
 //	}
-func NewPropertyImportFromRef(ref *openapi_spec.Ref) (PropertyImport, error) {
+func NewPropertyImportFromRef(ref *openapi_spec.Ref, mapping *PackageMapping) (PropertyImport, error) {
 	refPointer := ref.GetPointer()
 	if refPointer == nil || refPointer.IsEmpty() {
 		return PropertyImport{}, nil
 	}
 
-	namespace := strings.TrimPrefix(refPointer.String(), "/definitions/io.k8s.")
-	chunks := strings.Split(namespace, ".")
-	if len(chunks) < common.ChunkNumber {
-		return PropertyImport{},
-			fmt.Errorf("ref -> chunk: not enough chunks for %s: %+v", ref, chunks)
+	propImport, err := mapping.Resolve(strings.TrimPrefix(refPointer.String(), "/definitions/"))
+	if err != nil {
+		return PropertyImport{}, errors.Wrapf(err, "ref -> package: cannot resolve %s", ref)
 	}
 
-	namespaceChunks := chunks[0 : len(chunks)-1]
-	typeName := chunks[len(chunks)-1]
-
-	alias := strings.Join(namespaceChunks, "_")
-	alias = strings.ReplaceAll(alias, "-", "")
-
-	return PropertyImport{
-		TypeName:    typeName,
-		Alias:       alias,
-		PackageName: strings.Join(namespaceChunks, "/"),
-	}, nil
+	return propImport, nil
 }
@@ -26,6 +26,12 @@ func TestNewPropertyImportFromRef(t *testing.T) {
 			expectedAlias:       "api_apiserverinternal_v1alpha1",
 			expectedTypeName:    "StorageVersionCondition",
 		},
+		{
+			ref:                 "#/definitions/com.example.v1.Widget",
+			expectedPackageName: "com/example/v1",
+			expectedAlias:       "com_example_v1",
+			expectedTypeName:    "Widget",
+		},
 		{
 			ref:                 "",
 			expectedPackageName: "",
@@ -40,7 +46,7 @@ func TestNewPropertyImportFromRef(t *testing.T) {
 			t.Errorf("cannot create ref from url %s: %v", testCase.ref, err)
 		}
 
-		propImport, err := NewPropertyImportFromRef(&ref)
+		propImport, err := NewPropertyImportFromRef(&ref, DefaultPackageMapping())
 		if err != nil {
 			t.Errorf("unexpected error while parsing %s: %v", testCase.ref, err)
 		}
@@ -94,7 +100,7 @@ func TestPropertyImportToMap(t *testing.T) {
 			t.Errorf("cannot create ref from url %s: %v", testCase.ref, err)
 		}
 
-		propImport, err := NewPropertyImportFromRef(&ref)
+		propImport, err := NewPropertyImportFromRef(&ref, DefaultPackageMapping())
 		if err != nil {
 			t.Errorf("unexpected error while parsing %s: %v", testCase.ref, err)
 		}
@@ -0,0 +1,52 @@
+package swaggerhelpers
+
+// TypeOverride replaces the Go type of a definition with an existing type,
+// provided by another package. The definition is not generated, and all the
+// properties referencing it use the existing type instead.
+type TypeOverride struct {
+	// Definition is the id of the replaced definition
+	Definition string
+	// Package is the import path of the package providing the type
+	Package string
+	// Alias used when importing the package, derived from its path when empty
+	Alias string
+	// Type is the name of the Go type
+	Type string
+}
+
+// GoType returns the qualified name of the type, like
+// `example.com/quantity.Quantity`.
+func (o *TypeOverride) GoType() string {
+	return o.Package + "." + o.Type
+}
+
+// toMap converts the override into a swagger x-go-type extension. An empty
+// alias is derived from the package path by the emitter.
+func (o *TypeOverride) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"import": map[string]string{
+			"package": o.Package,
+			"alias":   o.Alias,
+		},
+		"type": o.Type,
+	}
+}
+
+// TypeOverrides indexes the type overrides by definition id.
+type TypeOverrides map[string]TypeOverride
+
+// NewTypeOverrides indexes the overrides by definition id. The overrides are
+// expected to be valid, they are checked when loading the configuration.
+func NewTypeOverrides(overrides []TypeOverride) TypeOverrides {
+	indexed := make(TypeOverrides, len(overrides))
+	for _, override := range overrides {
+		indexed[override.Definition] = override
+	}
+	return indexed
+}
+
+// Lookup returns the override of the definition with the given id, if any.
+func (o TypeOverrides) Lookup(id string) (TypeOverride, bool) {
+	override, found := o[id]
+	return override, found
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+)
+
+// generatorVersion returns the version of the generator, which is part of
+// the key of the cached models.
+//
+// Released binaries report their module version, binaries built from a clean
+// git checkout report the commit. The digest of the executable is used
+// otherwise, since local changes can alter the generated code.
+func generatorVersion() (string, error) {
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		settings := make(map[string]string)
+		for _, setting := range buildInfo.Settings {
+			settings[setting.Key] = setting.Value
+		}
+
+		switch {
+		case buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" && settings["vcs.modified"] != "true":
+			return buildInfo.Main.Version, nil
+		case settings["vcs.revision"] != "" && settings["vcs.modified"] == "false":
+			return settings["vcs.revision"], nil
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "cannot find the executable of the generator")
+	}
+	file, err := os.Open(executable)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", executable)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", executable)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}